@@ -11,16 +11,36 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"load-balancer/internal/accesslog"
+	"load-balancer/internal/admin"
+	"load-balancer/internal/backend"
 	"load-balancer/internal/balancer"
+	"load-balancer/internal/breaker"
 	"load-balancer/internal/config"
 	"load-balancer/internal/health"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/outlier"
 	"load-balancer/internal/prettylog"
+	"load-balancer/internal/proxy"
 	"load-balancer/internal/ratelimiter"
+	"load-balancer/internal/requestid"
 	"load-balancer/internal/server"
+	"load-balancer/internal/sticky"
+	"load-balancer/internal/tracing"
+	"load-balancer/internal/upstreamtls"
 	"log"
 	"log/slog"
 	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -37,9 +57,11 @@ func main() {
 	cfg := loadConfig()
 
 	// --- LOGGER ---
-	prettylog.InitLogger(cfg.LogLevel) // TODO: Уровень из конфига
+	setupLogger(cfg)
 	slog.Info("config initialized")
-	slog.Info("logger initialized", slog.String("level", cfg.LogLevel))
+	slog.Info("logger initialized",
+		slog.String("level", cfg.LogLevel),
+		slog.String("format", cfg.LogFormat))
 	slog.Info("Application starting...")
 
 	// --- APP CONTEXT ---
@@ -50,23 +72,97 @@ func main() {
 
 	// --- BALANCER ---
 	// Balancer обновляется через HealthChecker's OnUpdate callback списком живых серверов.
-	// Стратегия пока не обновляется "на лету".
-	b := setupBalancer(cfg)
+	// Стратегия меняется при перезагрузке конфигурации и через API администратора.
+	b, strategy := setupBalancer(cfg)
+
+	// --- MAINTENANCE ---
+	// Режимы draining/disabled задаются оператором и действуют поверх результатов health checker'а
+	maintenance := setupMaintenance(b)
+
+	// --- CIRCUIT BREAKER ---
+	var breakers *breaker.Set
+	if cfg.Breaker.Enabled {
+		breakers = setupCircuitBreaker(cfg, b)
+	}
+
+	// --- OUTLIER DETECTION ---
+	// Пассивная проверка по реальным ответам; живые для балансировщика - прошедшие
+	// активную проверку и не исключенные детектором
+	var outliers *outlier.Detector
+	if cfg.Outlier.Enabled {
+		outliers = setupOutlierDetection(cfg, b)
+	}
 
 	// --- RATE LIMITER ---
 	rl := setupRateLimiter(appCtx, cfg, &appWg)
 
 	// --- HEALTH CHECKER ---
-	setupAndRunHealthChecker(appCtx, &appWg, cfg, b)
+	hc := setupAndRunHealthChecker(appCtx, &appWg, cfg, b)
+
+	// --- ADMIN API ---
+	// Отдельный порт; включение, порт и токен применяются только при старте
+	if cfg.Admin.Enabled {
+		setupAndRunAdminServer(appCtx, &appWg, cfg, &admin.Server{
+			Balancer:     b,
+			Strategy:     strategy,
+			Maintenance:  maintenance,
+			Health:       hc,
+			Breakers:     breakers,
+			Outliers:     outliers,
+			RateLimiter:  rl,
+			Config:       config.Get,
+			Token:        cfg.Admin.Token,
+			DrainTimeout: cfg.Admin.DrainTimeout,
+		})
+	}
+
+	// --- METRICS ---
+	// Отдельный порт, чтобы не занимать пути проксируемого трафика; применяется только при старте
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = setupMetrics(hc, rl)
+		setupAndRunMetricsServer(appCtx, &appWg, cfg, m)
+	}
+
+	// --- TRACING ---
+	// Применяется только при старте; накопленные спаны отправляются при остановке
+	var tracer trace.Tracer
+	if cfg.Tracing.Enabled {
+		if tp := setupTracing(appCtx, cfg); tp != nil {
+			defer shutdownTracing(tp)
+			tracer = tp.Tracer(tracing.TracerName)
+		}
+	}
+
+	// --- ACCESS LOG ---
+	// Включение, формат и файл применяются только при старте; файл переоткрывается по SIGUSR1
+	var al *accesslog.Logger
+	if cfg.AccessLog.Enabled {
+		var closeLog func()
+		al, closeLog = setupAccessLog(appCtx, &appWg, cfg)
+		defer closeLog()
+	}
+
+	// --- PROXY POOL ---
+	proxies := setupProxyPool(cfg)
+	defer proxies.Close()
+	watchUpstreamTLS(appCtx, cfg, proxies, hc)
 
 	// --- HANDLER ---
-	h := setupHandler(b)
+	h := setupHandler(cfg, b, proxies, m, tracer, strategy.Name)
 
 	// --- HTTP SERVER ---
-	s := setupHttpServer(cfg, h, rl) // rl передается для middleware
+	s := setupHttpServer(cfg, h, rl, tracer, al, setupRequestID(cfg)) // rl передается для middleware
+
+	// --- HTTPS SERVER ---
+	// Параметры TLS применяются только при старте, сертификаты перечитываются при изменении файлов
+	servers := []*http.Server{s}
+	if cfg.Server.TLS.Enabled {
+		servers = append(servers, setupTLSServer(appCtx, cfg, s, h))
+	}
 
 	// --- RUN SERVER + GRACEFUL SHUTDOWN ---
-	server.Run(appCtx, appCancel, s)
+	server.Run(appCtx, appCancel, servers...)
 
 	// Ожидаем завершения всех фоновых горутин, управляемых appWg
 	//slog.Info("Waiting for all application components to stop...")
@@ -82,29 +178,133 @@ func loadConfig() *config.Config {
 	return config.Get()
 }
 
-func setupBalancer(cfg *config.Config) balancer.Balancer {
-	factory := balancer.NewStrategyFactory()
-	b := factory.Create(cfg.Strategy, cfg.Backends)
-	ab := balancer.NewAtomicBalancer(b)
+// setupLogger настраивает slog. Формат применяется только при старте, уровень - при каждой перезагрузке.
+func setupLogger(cfg *config.Config) {
+	err := prettylog.Init(os.Stdout, cfg.LogFormat, cfg.LogLevel, cfg.LogSource)
+	// Записи с контекстом запроса получают его X-Request-ID
+	slog.SetDefault(slog.New(requestid.LogHandler(slog.Default().Handler())))
+	if err != nil {
+		slog.Error("Invalid log_format, using pretty", slog.String("error", err.Error()))
+	}
+
+	config.Subscribe(func(newCfg *config.Config) {
+		prettylog.SetLevel(newCfg.LogLevel)
+		slog.Info("Log level updated.", slog.String("level", newCfg.LogLevel))
+	})
+}
+
+func setupBalancer(cfg *config.Config) (*balancer.AtomicBalancer, *balancer.StrategySwitcher) {
+	stats := balancer.NewStats()
+	factory := balancer.NewStrategyFactory(stats)
+	b := factory.Create(cfg.Strategy, nil)
+	ab := balancer.NewAtomicBalancer(b, stats)
+	strategy := balancer.NewStrategySwitcher(ab, factory, cfg.Strategy)
+	ab.SetMinHealthy(cfg.Failover.MinHealthy)
+	ab.SetSlowStart(slowStart(cfg))
+	// До первой проверки здоровья считаем живыми все бэкенды из конфигурации
+	ab.Update(cfg.BackendList())
 
 	slog.Info("balancer initialized", slog.String("strategy", cfg.Strategy))
 
+	// Стратегия из последней загруженной конфигурации. Сравнение идет с ней, а не с текущей
+	// стратегией, чтобы перезагрузка без изменения strategy не отменяла выбор администратора.
+	var mu sync.Mutex
+	configured := cfg.Strategy
+
 	config.Subscribe(func(newCfg *config.Config) {
-		// 3. Обновить стратегию балансировщика (если она изменилась)
-		// Если стратегия меняется, возможно, нужно пересоздать объект балансировщика.
-		// И обновить ссылку на него в server.Handler.
-		// А также callback в HealthChecker, т.к. он захватывал 'b' по значению.
-		// TODO
-		if newCfg.Strategy != cfg.Strategy {
-			newBalancer := factory.Create(newCfg.Strategy, newCfg.Backends)
-			ab.SetStrategy(newBalancer) // Атомарная замена
+		mu.Lock()
+		changed := newCfg.Strategy != configured
+		configured = newCfg.Strategy
+		mu.Unlock()
+
+		if changed {
+			if err := strategy.Switch(newCfg.Strategy); err != nil {
+				slog.Error("Strategy not changed", slog.String("error", err.Error()))
+			}
 		}
+		ab.SetMinHealthy(newCfg.Failover.MinHealthy)
+		ab.SetSlowStart(slowStart(newCfg))
 
 		// Обновление серверов не нежно, т.к. Health Checker
 		// подхватывает это изменение и сообщает балансировщику
 	})
 
-	return ab
+	return ab, strategy
+}
+
+func slowStart(cfg *config.Config) balancer.SlowStart {
+	return balancer.SlowStart{
+		Window:     cfg.SlowStart.Window,
+		MinFactor:  float64(cfg.SlowStart.MinWeightPercent) / 100,
+		Aggression: cfg.SlowStart.Aggression,
+	}
+}
+
+// setupMaintenance подключает режимы draining/disabled к балансировщику.
+// Режимы бэкендов, удаленных из конфигурации, сбрасываются при перезагрузке.
+func setupMaintenance(ab *balancer.AtomicBalancer) *balancer.Maintenance {
+	m := balancer.NewMaintenance(ab.Stats(), ab.Refresh)
+	ab.AddFilter(m)
+
+	config.Subscribe(func(newCfg *config.Config) {
+		m.RetainBackends(backend.URLs(newCfg.BackendList()))
+	})
+	return m
+}
+
+// setupCircuitBreaker подключает circuit breaker к балансировщику.
+// Включение применяется только при старте, параметры обновляются при перезагрузке конфигурации.
+func setupCircuitBreaker(cfg *config.Config, ab *balancer.AtomicBalancer) *breaker.Set {
+	breakerConfig := func(cfg *config.Config) breaker.Config {
+		return breaker.Config{
+			ConsecutiveFailures: *cfg.Breaker.ConsecutiveFailures,
+			ErrorRatePercent:    *cfg.Breaker.ErrorRatePercent,
+			MinRequests:         cfg.Breaker.MinRequests,
+			Window:              cfg.Breaker.Window,
+			OpenTimeout:         cfg.Breaker.OpenTimeout,
+			HalfOpenProbes:      cfg.Breaker.HalfOpenProbes,
+		}
+	}
+
+	cb := breaker.New(breakerConfig(cfg), ab.Refresh)
+	ab.AddFilter(cb)
+
+	config.Subscribe(func(newCfg *config.Config) {
+		cb.UpdateConfig(breakerConfig(newCfg))
+		slog.Info("Circuit breaker configuration updated.")
+	})
+
+	slog.Info("circuit breaker enabled")
+	return cb
+}
+
+// setupOutlierDetection подключает пассивную проверку здоровья к балансировщику.
+// Включение применяется только при старте, параметры обновляются при перезагрузке конфигурации.
+func setupOutlierDetection(cfg *config.Config, ab *balancer.AtomicBalancer) *outlier.Detector {
+	outlierConfig := func(cfg *config.Config) outlier.Config {
+		return outlier.Config{
+			ConsecutiveErrors:  *cfg.Outlier.ConsecutiveErrors,
+			ErrorRatePercent:   *cfg.Outlier.ErrorRatePercent,
+			MinRequests:        cfg.Outlier.MinRequests,
+			Interval:           cfg.Outlier.Interval,
+			BaseEjectionTime:   cfg.Outlier.BaseEjectionTime,
+			MaxEjectionTime:    cfg.Outlier.MaxEjectionTime,
+			MaxEjectionPercent: cfg.Outlier.MaxEjectionPercent,
+		}
+	}
+
+	d := outlier.New(outlierConfig(cfg), ab.Refresh)
+	d.SetBackends(cfg.BackendList())
+	ab.AddFilter(d)
+
+	config.Subscribe(func(newCfg *config.Config) {
+		d.UpdateConfig(outlierConfig(newCfg))
+		d.SetBackends(newCfg.BackendList())
+		slog.Info("Outlier detection configuration updated.")
+	})
+
+	slog.Info("outlier detection enabled")
+	return d
 }
 
 // setupRateLimiter создает/настраивает распределенный ограничитель запросов
@@ -147,14 +347,23 @@ func setupRateLimiter(appCtx context.Context, cfg *config.Config, appWg *sync.Wa
 }
 
 // setupAndRunHealthChecker запускает проверку здоровья бэкендов
-func setupAndRunHealthChecker(appCtx context.Context, appWg *sync.WaitGroup, cfg *config.Config, b balancer.Balancer) {
+func setupAndRunHealthChecker(
+	appCtx context.Context,
+	appWg *sync.WaitGroup,
+	cfg *config.Config,
+	b balancer.Balancer,
+) *health.Checker {
 	hc := health.NewChecker(
-		cfg.Backends,
+		cfg.BackendList(),
 		cfg.HealthCheck.IntervalSeconds,
 		cfg.HealthCheck.TimeoutSeconds,
-		cfg.HealthCheck.Path,
-		func(live []string) { b.Update(live) },
+		healthCheck(cfg.HealthCheck.HealthProbeConfig),
+		func(live []backend.Backend) { b.Update(live) },
 	)
+	hc.SetThresholds(cfg.HealthCheck.HealthyThreshold, cfg.HealthCheck.UnhealthyThreshold)
+	hc.SetScheduling(cfg.HealthCheck.Jitter, cfg.HealthCheck.MaxConcurrentProbes)
+	hc.SetOverrides(healthOverrides(cfg))
+	hc.SetTLS(healthTLS(cfg))
 
 	appWg.Add(1)
 	go func() {
@@ -166,10 +375,14 @@ func setupAndRunHealthChecker(appCtx context.Context, appWg *sync.WaitGroup, cfg
 		slog.Info("Stopping health checker for reconfiguration...")
 		hc.Stop() // Блокирующий вызов, дождется остановки
 		slog.Info("Health checker stopped. Updating configuration...")
-		hc.UpdateConfig(newCfg.Backends,
+		hc.UpdateConfig(newCfg.BackendList(),
 			newCfg.HealthCheck.IntervalSeconds,
 			newCfg.HealthCheck.TimeoutSeconds,
-			newCfg.HealthCheck.Path)
+			healthCheck(newCfg.HealthCheck.HealthProbeConfig))
+		hc.SetThresholds(newCfg.HealthCheck.HealthyThreshold, newCfg.HealthCheck.UnhealthyThreshold)
+		hc.SetScheduling(newCfg.HealthCheck.Jitter, newCfg.HealthCheck.MaxConcurrentProbes)
+		hc.SetOverrides(healthOverrides(newCfg))
+		hc.SetTLS(healthTLS(newCfg)) // Файлы сертификатов перечитываются при каждой перезагрузке
 
 		slog.Info("Health checker configuration updated. Restarting...")
 		hc.Start(appCtx) // Запускаем новый цикл с обновленной конфигурацией
@@ -177,11 +390,318 @@ func setupAndRunHealthChecker(appCtx context.Context, appWg *sync.WaitGroup, cfg
 	})
 
 	slog.Info("health checker started")
+	return hc
+}
+
+// setupRequestID разбирает доверенные источники X-Request-ID и обновляет их при перезагрузке конфигурации
+func setupRequestID(cfg *config.Config) *requestid.Trusted {
+	trusted := &requestid.Trusted{}
+	update := func(cfg *config.Config) {
+		nets, err := requestid.ParseTrusted(cfg.RequestID.TrustedSources)
+		if err != nil {
+			slog.Error("Invalid request_id.trusted_sources, incoming request IDs are not trusted",
+				slog.String("error", err.Error()))
+		}
+		trusted.Set(nets)
+	}
+	update(cfg)
+
+	config.Subscribe(update)
+	return trusted
+}
+
+// setupAccessLog создает журнал запросов в log_file (пусто - stdout).
+// Возвращает функцию закрытия файла. При ошибке журнал пишется в stdout.
+func setupAccessLog(appCtx context.Context, appWg *sync.WaitGroup, cfg *config.Config) (*accesslog.Logger, func()) {
+	var w io.Writer = os.Stdout
+	closeLog := func() {}
+
+	if cfg.LogFile != "" {
+		f, err := accesslog.OpenFile(cfg.LogFile)
+		if err != nil {
+			slog.Error("Access log file is not available, using stdout", slog.String("error", err.Error()))
+		} else {
+			w = f
+			closeLog = func() { _ = f.Close() }
+			reopenAccessLogOnSignal(appCtx, appWg, f)
+		}
+	}
+
+	al, err := accesslog.New(w, cfg.AccessLog.Format)
+	if err != nil {
+		slog.Error("Invalid access log format, using combined", slog.String("error", err.Error()))
+		al, _ = accesslog.New(w, accesslog.FormatCombined)
+	}
+
+	slog.Info("access log enabled", slog.String("format", cfg.AccessLog.Format), slog.String("file", cfg.LogFile))
+	return al, closeLog
 }
 
-func setupHttpServer(cfg *config.Config, handler *server.Handler, l *ratelimiter.Limiter) *http.Server {
+// setupMetrics подключает метрики к health checker'у, rate limiter'у и перезагрузке конфигурации.
+// Метрики запросов собирает обработчик (server.WithMetrics).
+func setupMetrics(hc *health.Checker, rl *ratelimiter.Limiter) *metrics.Metrics {
+	m := metrics.New()
+
+	hc.Subscribe(func(ev health.Event) {
+		m.SetHealthy(ev.Backend, ev.To == health.StateHealthy)
+	})
+	hc.SubscribeProbes(func(p health.Probe) {
+		m.ObserveProbe(p.Backend, p.Reason, p.Latency)
+	})
+	// Checker уже запущен: переходы до подписки не пришли событиями, берем текущие состояния
+	for _, st := range hc.Statuses() {
+		if st.State != health.StateUnknown {
+			m.SetHealthy(st.Backend, st.State == health.StateHealthy)
+		}
+	}
+	rl.OnDecision(func(d ratelimiter.Decision) {
+		m.ObserveRateLimit(d.Rule, d.Allowed)
+	})
+	config.OnReload(m.ObserveConfigReload)
+	config.Subscribe(func(newCfg *config.Config) {
+		// Серии удаленных из конфигурации бэкендов больше не обновляются
+		m.RetainBackends(backend.URLs(newCfg.BackendList()))
+	})
+
+	slog.Info("metrics enabled")
+	return m
+}
+
+// setupTracing создает провайдер трассировки. При ошибке трассировка выключается.
+func setupTracing(ctx context.Context, cfg *config.Config) *sdktrace.TracerProvider {
+	tp, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		slog.Error("Tracing is disabled", slog.String("error", err.Error()))
+		return nil
+	}
+
+	slog.Info("tracing enabled",
+		slog.String("exporter", cfg.Tracing.Exporter),
+		slog.Float64("sample_ratio", cfg.Tracing.SampleRatio))
+	return tp
+}
+
+func shutdownTracing(tp *sdktrace.TracerProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tp.Shutdown(ctx); err != nil {
+		slog.Error("Tracing shutdown error", slog.String("error", err.Error()))
+	}
+}
+
+// setupAndRunAdminServer запускает API администратора и останавливает его вместе с приложением
+func setupAndRunAdminServer(appCtx context.Context, appWg *sync.WaitGroup, cfg *config.Config, a *admin.Server) {
+	if cfg.Admin.Token == "" {
+		slog.Warn("Admin API is enabled without a token, anyone with access to the port can control the balancer")
+	}
+
+	s := &http.Server{
+		Addr:         ":" + cfg.Admin.Port,
+		Handler:      a.Handler(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	runAuxServer(appCtx, appWg, "Admin", s)
+}
+
+// setupAndRunMetricsServer отдает метрики Prometheus на отдельном порту
+func setupAndRunMetricsServer(appCtx context.Context, appWg *sync.WaitGroup, cfg *config.Config, m *metrics.Metrics) {
 	mux := http.NewServeMux()
-	mux.Handle("/", ratelimiter.Middleware(l, handler))
+	mux.Handle(cfg.Metrics.Path, m.Handler())
+
+	s := &http.Server{
+		Addr:         ":" + cfg.Metrics.Port,
+		Handler:      mux,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+	runAuxServer(appCtx, appWg, "Metrics", s)
+}
+
+// runAuxServer запускает вспомогательный сервер (API администратора, метрики)
+// и останавливает его вместе с приложением. Ошибка сервера не останавливает балансировщик.
+func runAuxServer(appCtx context.Context, appWg *sync.WaitGroup, name string, s *http.Server) {
+	appWg.Add(2)
+	go func() {
+		defer appWg.Done()
+		slog.Info(name+" server starting", slog.String("address", s.Addr))
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error(name+" server ListenAndServe error", slog.String("error", err.Error()))
+		}
+	}()
+	go func() {
+		defer appWg.Done()
+		<-appCtx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			slog.Error(name+" server shutdown error", slog.String("error", err.Error()))
+		}
+		slog.Info(name + " server stopped.")
+	}()
+}
+
+// healthCheck переводит параметры проверки из конфигурации.
+// Некорректные тип проверки, коды ответа и регулярные выражения пропускаются с ошибкой в логе.
+func healthCheck(p config.HealthProbeConfig) health.Check {
+	check := health.Check{
+		Type:         strings.ToLower(p.Type),
+		Port:         p.Port,
+		Path:         p.Path,
+		Method:       strings.ToUpper(p.Method),
+		Headers:      p.Headers,
+		BodyContains: p.BodyContains,
+		Send:         p.Send,
+		Expect:       p.Expect,
+		Service:      p.GRPCService,
+	}
+	if _, err := health.NewProber(check, nil, nil); err != nil {
+		slog.Error("Invalid health check type, using http", slog.String("error", err.Error()))
+		check.Type = health.TypeHTTP
+	}
+
+	statuses, err := health.ParseStatusRanges(p.ExpectedStatus)
+	if err != nil {
+		slog.Error("Invalid health check expected_status, using 200", slog.String("error", err.Error()))
+	}
+	check.Statuses = statuses
+
+	if p.BodyRegex != "" {
+		re, err := regexp.Compile(p.BodyRegex)
+		if err != nil {
+			slog.Error("Invalid health check body_regex, ignored", slog.String("error", err.Error()))
+		}
+		check.BodyRegex = re
+	}
+	return check
+}
+
+func healthOverrides(cfg *config.Config) map[string]health.Check {
+	overrides := make(map[string]health.Check)
+	for url, p := range cfg.HealthProbeOverrides() {
+		overrides[url] = healthCheck(p)
+	}
+	return overrides
+}
+
+// watchUpstreamTLS перечитывает сертификаты и CA бэкендов https при изменении файлов,
+// в т.ч. замененных по тому же пути: пересоздаются соединения прокси и проверок здоровья.
+// Список файлов обновляется при перезагрузке конфигурации.
+func watchUpstreamTLS(ctx context.Context, cfg *config.Config, proxies *proxy.Pool, hc *health.Checker) {
+	var mu sync.Mutex
+	cancel := func() {}
+
+	watch := func(cfg *config.Config) {
+		mu.Lock()
+		defer mu.Unlock()
+		cancel()
+
+		files := upstreamTLSFiles(cfg)
+		if len(files) == 0 {
+			cancel = func() {}
+			return
+		}
+		var watchCtx context.Context
+		watchCtx, cancel = context.WithCancel(ctx)
+		err := config.WatchFiles(watchCtx, files, 500*time.Millisecond, func() {
+			if err := proxies.ReloadTLS(); err != nil {
+				slog.Error("Upstream TLS: some backends keep previous certificates", slog.String("error", err.Error()))
+			}
+			hc.SetTLS(healthTLS(config.Get()))
+			slog.Info("Upstream TLS certificates reloaded.")
+		})
+		if err != nil {
+			slog.Error("Upstream TLS certificates will not be reloaded", slog.String("error", err.Error()))
+		}
+	}
+	watch(cfg)
+
+	config.Subscribe(watch)
+}
+
+// upstreamTLSFiles возвращает файлы сертификатов, ключей и CA из proxy.tls и переопределений бэкендов
+func upstreamTLSFiles(cfg *config.Config) []string {
+	var files []string
+	add := func(t config.UpstreamTLSConfig) {
+		for _, f := range []string{t.CAFile, t.CertFile, t.KeyFile} {
+			if f != "" && !slices.Contains(files, f) {
+				files = append(files, f)
+			}
+		}
+	}
+	add(cfg.Proxy.TLS)
+	for _, t := range cfg.UpstreamTLSOverrides() {
+		add(t)
+	}
+	return files
+}
+
+// healthTLS собирает параметры TLS проверок здоровья из proxy.tls и переопределений бэкендов.
+// При ошибке чтения файлов бэкенд проверяется с параметрами Go по умолчанию, ошибка пишется в лог.
+func healthTLS(cfg *config.Config) (*tls.Config, map[string]*tls.Config) {
+	def, err := upstreamtls.New(upstreamTLS(cfg.Proxy.TLS))
+	if err != nil {
+		slog.Error("Invalid proxy.tls, health checks use default TLS", slog.String("error", err.Error()))
+	}
+
+	overrides := make(map[string]*tls.Config)
+	for url, t := range upstreamTLSOverrides(cfg) {
+		c, err := upstreamtls.New(t)
+		if err != nil {
+			slog.Error("Invalid backend tls, health checks use default TLS",
+				slog.String("backend", url), slog.String("error", err.Error()))
+		}
+		overrides[url] = c
+	}
+	return def, overrides
+}
+
+func upstreamTLS(t config.UpstreamTLSConfig) upstreamtls.Config {
+	return upstreamtls.Config{
+		CAFile:             t.CAFile,
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+}
+
+func upstreamTLSOverrides(cfg *config.Config) map[string]upstreamtls.Config {
+	overrides := make(map[string]upstreamtls.Config)
+	for url, t := range cfg.UpstreamTLSOverrides() {
+		overrides[url] = upstreamTLS(t)
+	}
+	return overrides
+}
+
+func setupHttpServer(
+	cfg *config.Config,
+	handler *server.Handler,
+	l *ratelimiter.Limiter,
+	tracer trace.Tracer,
+	al *accesslog.Logger,
+	trusted *requestid.Trusted,
+) *http.Server {
+	proxied := ratelimiter.Middleware(l, handler)
+	if tracer != nil {
+		// Серверный спан создается до rate limiter, чтобы отклоненные запросы тоже попадали в трассы
+		proxied = tracing.Middleware(tracer, proxied)
+	}
+	if al != nil {
+		proxied = accesslog.Middleware(al, proxied)
+	}
+	// ID назначается первым: он нужен журналу запросов, логам и ответам с ошибкой
+	proxied = requestid.Middleware(trusted, proxied)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", proxied)
 	mux.HandleFunc("/health", handler.HealthCheck)
 
 	return &http.Server{
@@ -192,6 +712,141 @@ func setupHttpServer(cfg *config.Config, handler *server.Handler, l *ratelimiter
 	}
 }
 
-func setupHandler(b balancer.Balancer) *server.Handler {
-	return server.NewHandler(b)
+// setupTLSServer создает HTTPS-сервер с обработчиком plain и следит за файлами сертификатов.
+// Если сертификаты не загружаются, приложение не запускается: молча отдавать
+// трафик только по HTTP при включенном TLS нельзя.
+func setupTLSServer(ctx context.Context, cfg *config.Config, plain *http.Server, h *server.Handler) *http.Server {
+	files := make([]server.CertificateFiles, 0, len(cfg.Server.TLS.Certificates))
+	for _, c := range cfg.Server.TLS.Certificates {
+		files = append(files, server.CertificateFiles{CertFile: c.CertFile, KeyFile: c.KeyFile})
+	}
+	certs, err := server.LoadCertificates(files)
+	if err != nil {
+		log.Fatal("TLS certificates error: ", err.Error())
+	}
+	tlsConfig, err := server.NewTLSConfig(certs, server.TLSOptions{
+		MinVersion:   cfg.Server.TLS.MinVersion,
+		CipherSuites: cfg.Server.TLS.CipherSuites,
+		DisableHTTP2: cfg.Server.TLS.DisableHTTP2,
+	})
+	if err != nil {
+		log.Fatal("TLS config error: ", err.Error())
+	}
+
+	err = config.WatchFiles(ctx, certs.Files(), 500*time.Millisecond, func() {
+		if err := certs.Reload(); err != nil {
+			slog.Error("TLS certificates not reloaded, keeping previous", slog.String("error", err.Error()))
+			return
+		}
+		slog.Info("TLS certificates reloaded.")
+	})
+	if err != nil {
+		slog.Error("TLS certificates will not be reloaded", slog.String("error", err.Error()))
+	}
+
+	s := server.NewTLSServer(plain, ":"+cfg.Server.TLS.Port, tlsConfig)
+	if cfg.Server.TLS.RedirectHTTP {
+		// Проверка живости балансировщика остается доступной по HTTP
+		mux := http.NewServeMux()
+		mux.Handle("/", server.RedirectHTTPS(cfg.Server.TLS.Port))
+		mux.HandleFunc("/health", h.HealthCheck)
+		plain.Handler = mux
+	}
+
+	slog.Info("TLS enabled",
+		slog.String("port", cfg.Server.TLS.Port),
+		slog.Int("certificates", len(files)),
+		slog.Bool("redirect_http", cfg.Server.TLS.RedirectHTTP))
+	return s
+}
+
+// setupProxyPool создает пул соединений с бэкендами и обновляет его при перезагрузке конфигурации
+func setupProxyPool(cfg *config.Config) *proxy.Pool {
+	transportConfig := func(cfg *config.Config) proxy.TransportConfig {
+		return proxy.TransportConfig{
+			MaxIdleConns:          cfg.Proxy.MaxIdleConns,
+			MaxIdleConnsPerHost:   cfg.Proxy.MaxIdleConnsPerHost,
+			IdleConnTimeout:       cfg.Proxy.IdleConnTimeout,
+			DialTimeout:           cfg.Proxy.DialTimeout,
+			KeepAlive:             cfg.Proxy.KeepAlive,
+			TLSHandshakeTimeout:   cfg.Proxy.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: cfg.Proxy.ResponseHeaderTimeout,
+			DisableKeepAlives:     cfg.Proxy.DisableKeepAlives,
+			TLS:                   upstreamTLS(cfg.Proxy.TLS),
+		}
+	}
+
+	pool := proxy.NewPool()
+	if err := pool.Update(transportConfig(cfg), backend.URLs(cfg.BackendList()), upstreamTLSOverrides(cfg)); err != nil {
+		slog.Error("Proxy pool: some backends are skipped", slog.String("error", err.Error()))
+	}
+
+	config.Subscribe(func(newCfg *config.Config) {
+		if err := pool.Update(transportConfig(newCfg), backend.URLs(newCfg.BackendList()), upstreamTLSOverrides(newCfg)); err != nil {
+			slog.Error("Proxy pool: some backends are skipped", slog.String("error", err.Error()))
+		}
+		slog.Info("Proxy pool updated.")
+	})
+
+	slog.Info("proxy pool initialized")
+	return pool
+}
+
+func setupHandler(
+	cfg *config.Config,
+	b balancer.TrackingBalancer,
+	proxies *proxy.Pool,
+	m *metrics.Metrics,
+	tracer trace.Tracer,
+	strategy func() string,
+) *server.Handler {
+	var options []server.HandlerOption
+	if cfg.Sticky.Enabled {
+		options = append(options, server.WithStickySessions(setupStickySessions(cfg)))
+	}
+	if m != nil {
+		options = append(options, server.WithMetrics(m))
+	}
+	if tracer != nil {
+		options = append(options, server.WithTracing(tracer, strategy))
+	}
+
+	retryPolicy := func(cfg *config.Config) *server.RetryPolicy {
+		p, err := server.NewRetryPolicy(
+			cfg.Retry.MaxAttempts,
+			cfg.Retry.RetryOn,
+			cfg.Retry.Methods,
+			cfg.Retry.MaxBodyBytes,
+			cfg.Retry.Budget,
+		)
+		if err != nil {
+			slog.Error("Invalid retry config, retries disabled", slog.String("error", err.Error()))
+			return nil
+		}
+		return p
+	}
+	options = append(options, server.WithRetryPolicy(retryPolicy(cfg)))
+
+	h := server.NewHandler(b, proxies, options...)
+
+	config.Subscribe(func(newCfg *config.Config) {
+		h.SetRetryPolicy(retryPolicy(newCfg))
+		slog.Info("Retry policy updated.")
+	})
+
+	return h
+}
+
+// setupStickySessions создает sticky-сессии. Включение и секрет применяются только при старте,
+// список бэкендов обновляется при перезагрузке конфигурации.
+func setupStickySessions(cfg *config.Config) *sticky.Sessions {
+	s := sticky.New(cfg.Sticky.CookieName, cfg.Sticky.Secret, cfg.Sticky.TTL)
+	s.SetBackends(cfg.BackendList())
+
+	config.Subscribe(func(newCfg *config.Config) {
+		s.SetBackends(newCfg.BackendList())
+	})
+
+	slog.Info("sticky sessions enabled", slog.String("cookie", cfg.Sticky.CookieName))
+	return s
 }
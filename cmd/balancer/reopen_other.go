@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+	"load-balancer/internal/accesslog"
+	"sync"
+)
+
+// reopenAccessLogOnSignal ничего не делает: SIGUSR1 есть только в unix,
+// файл журнала запросов переоткрывается при перезапуске
+func reopenAccessLogOnSignal(appCtx context.Context, appWg *sync.WaitGroup, f *accesslog.File) {}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"load-balancer/internal/accesslog"
+	"sync"
+	"syscall"
+)
+
+// reopenAccessLogOnSignal переоткрывает файл журнала запросов по SIGUSR1 (после ротации)
+func reopenAccessLogOnSignal(appCtx context.Context, appWg *sync.WaitGroup, f *accesslog.File) {
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+		f.ReopenOnSignal(appCtx, syscall.SIGUSR1)
+	}()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"load-balancer/internal/apperror"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// client вызывает API администратора
+type client struct {
+	addr  string
+	token string
+	http  *http.Client
+}
+
+func newClient(addr, token string, timeout time.Duration) *client {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &client{
+		addr:  strings.TrimRight(addr, "/"),
+		token: token,
+		http:  &http.Client{Timeout: timeout},
+	}
+}
+
+// do отправляет body как JSON и разбирает ответ в out (если не nil).
+// Ответ с ошибкой возвращается как *apperror.AppError.
+func (c *client) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.addr+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		appErr := &apperror.AppError{Code: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(appErr); err != nil || appErr.Message == "" {
+			appErr.Message = resp.Status
+		}
+		return fmt.Errorf("%s %s: %w", method, path, appErr)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
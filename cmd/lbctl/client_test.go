@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"load-balancer/internal/admin"
+	"load-balancer/internal/apperror"
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/config"
+	"load-balancer/internal/ratelimiter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCLI запускает API администратора на httptest и возвращает cli с выводом в JSON
+func newTestCLI(t *testing.T, token string) (*cli, *bytes.Buffer) {
+	t.Helper()
+
+	cfg := &config.Config{
+		Strategy: "round-robin",
+		Backends: []config.BackendConfig{{URL: "http://a", Weight: 1}, {URL: "http://b", Weight: 1}},
+	}
+	stats := balancer.NewStats()
+	factory := balancer.NewStrategyFactory(stats)
+	ab := balancer.NewAtomicBalancer(factory.Create(cfg.Strategy, nil), stats)
+	maintenance := balancer.NewMaintenance(stats, ab.Refresh)
+	ab.AddFilter(maintenance)
+	ab.Update(cfg.BackendList())
+
+	rl := ratelimiter.NewLimiter(5, 1, nil)
+	rl.Allow("10.0.0.1")
+
+	srv := httptest.NewServer((&admin.Server{
+		Balancer:     ab,
+		Strategy:     balancer.NewStrategySwitcher(ab, factory, cfg.Strategy),
+		Maintenance:  maintenance,
+		RateLimiter:  rl,
+		Config:       func() *config.Config { return cfg },
+		Token:        "t0ken",
+		DrainTimeout: time.Second,
+	}).Handler())
+	t.Cleanup(srv.Close)
+
+	var out bytes.Buffer
+	return &cli{
+		client: newClient(strings.TrimPrefix(srv.URL, "http://"), token, time.Second),
+		out:    newPrinter(&out, formatJSON),
+	}, &out
+}
+
+// runJSON выполняет команду и разбирает ее JSON-вывод в v
+func runJSON(t *testing.T, c *cli, out *bytes.Buffer, v any, args ...string) {
+	t.Helper()
+	out.Reset()
+	if err := c.run(args); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	if err := json.Unmarshal(out.Bytes(), v); err != nil {
+		t.Fatalf("%v: invalid JSON output %q: %v", args, out.String(), err)
+	}
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	c, out := newTestCLI(t, "t0ken")
+
+	var backends []admin.BackendInfo
+	runJSON(t, c, out, &backends, "backends", "list")
+	if len(backends) != 2 || backends[0].URL != "http://a" {
+		t.Fatalf("backends = %+v", backends)
+	}
+
+	var state map[string]any
+	runJSON(t, c, out, &state, "backends", "drain", "http://a", "-timeout", "5s")
+	if state["url"] != "http://a" || state["admin_state"] != "draining" {
+		t.Errorf("drain state = %v", state)
+	}
+	runJSON(t, c, out, &state, "backends", "enable", "http://a")
+	if state["admin_state"] != "enabled" {
+		t.Errorf("enable state = %v", state)
+	}
+
+	var strategy struct {
+		Strategy string `json:"strategy"`
+	}
+	runJSON(t, c, out, &strategy, "strategy", "set", "least-connections")
+	runJSON(t, c, out, &strategy, "strategy", "show")
+	if strategy.Strategy != "least-connections" {
+		t.Errorf("strategy = %q", strategy.Strategy)
+	}
+
+	var bucket ratelimiter.BucketState
+	runJSON(t, c, out, &bucket, "ratelimit", "show", "10.0.0.1")
+	if bucket.ClientID != "10.0.0.1" {
+		t.Errorf("bucket = %+v", bucket)
+	}
+	var reset map[string]string
+	runJSON(t, c, out, &reset, "ratelimit", "reset", "10.0.0.1")
+	if reset["status"] != "reset" {
+		t.Errorf("reset = %v", reset)
+	}
+
+	// Ошибки API возвращаются как AppError с кодом ответа
+	err := c.run([]string{"backends", "enable", "http://unknown"})
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) || appErr.Code != http.StatusNotFound {
+		t.Errorf("unknown backend: err = %v, want AppError 404", err)
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	c, _ := newTestCLI(t, "wrong")
+
+	err := c.run([]string{"strategy", "show"})
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) || appErr.Code != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want AppError 401", err)
+	}
+	if !strings.Contains(err.Error(), "GET /strategy") {
+		t.Errorf("error %q does not name the request", err)
+	}
+}
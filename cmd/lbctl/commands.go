@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"load-balancer/internal/admin"
+	"load-balancer/internal/ratelimiter"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+)
+
+func (c *cli) backends(sub string, args []string) error {
+	switch sub {
+	case "list":
+		var list []admin.BackendInfo
+		if err := c.client.do(http.MethodGet, "/backends", nil, &list); err != nil {
+			return err
+		}
+		return c.out.print(list, func(t *tabwriter.Writer) {
+			row(t, "URL", "PRIORITY", "WEIGHT", "HEALTH", "SINCE", "IN-FLIGHT", "ADMIN", "CIRCUIT", "EJECTED", "SERVING")
+			for _, b := range list {
+				row(t, b.URL, b.Priority, b.Weight, b.Health, since(b.HealthSince), b.InFlight,
+					b.AdminState, dash(b.Circuit), until(b.EjectedUntil), b.Serving)
+			}
+		})
+
+	case "drain", "enable", "disable":
+		fs := flag.NewFlagSet("backends "+sub, flag.ExitOnError)
+		timeout := fs.Duration("timeout", 0, "Drain timeout (default: server setting)")
+		backendURL, err := parseOne(fs, args, "url")
+		if err != nil {
+			return err
+		}
+
+		req := admin.BackendRequest{URL: backendURL}
+		if sub == "drain" && *timeout > 0 {
+			req.Timeout = timeout.String()
+		}
+		var state map[string]any
+		if err := c.client.do(http.MethodPost, "/backends/"+sub, req, &state); err != nil {
+			return err
+		}
+		return c.out.print(state, func(t *tabwriter.Writer) {
+			row(t, "URL", "ADMIN", "IN-FLIGHT")
+			row(t, state["url"], state["admin_state"], state["in_flight"])
+		})
+
+	default:
+		return fmt.Errorf("unknown backends command %q", sub)
+	}
+}
+
+func (c *cli) strategy(sub string, args []string) error {
+	var resp struct {
+		Strategy  string   `json:"strategy"`
+		Available []string `json:"available"`
+	}
+
+	switch sub {
+	case "show":
+		if err := c.client.do(http.MethodGet, "/strategy", nil, &resp); err != nil {
+			return err
+		}
+	case "set":
+		name, err := parseOne(flag.NewFlagSet("strategy set", flag.ExitOnError), args, "name")
+		if err != nil {
+			return err
+		}
+		if err := c.client.do(http.MethodPut, "/strategy", admin.StrategyRequest{Strategy: name}, &resp); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown strategy command %q", sub)
+	}
+
+	return c.out.print(resp, func(t *tabwriter.Writer) {
+		row(t, "STRATEGY", "AVAILABLE")
+		row(t, resp.Strategy, fmt.Sprint(resp.Available))
+	})
+}
+
+func (c *cli) ratelimit(sub string, args []string) error {
+	var buckets []ratelimiter.BucketState
+	table := func(t *tabwriter.Writer) {
+		row(t, "CLIENT", "TOKENS", "CAPACITY", "RATE/S", "LAST SEEN")
+		for _, b := range buckets {
+			row(t, b.ClientID, b.Tokens, b.Capacity, b.RefillRate, b.LastSeen.Format(time.RFC3339))
+		}
+	}
+
+	switch sub {
+	case "list":
+		if err := c.client.do(http.MethodGet, "/ratelimit", nil, &buckets); err != nil {
+			return err
+		}
+		return c.out.print(buckets, table)
+
+	case "show":
+		client, err := parseOne(flag.NewFlagSet("ratelimit show", flag.ExitOnError), args, "client")
+		if err != nil {
+			return err
+		}
+		var bucket ratelimiter.BucketState
+		if err := c.client.do(http.MethodGet, "/ratelimit/"+url.PathEscape(client), nil, &bucket); err != nil {
+			return err
+		}
+		buckets = append(buckets, bucket)
+		return c.out.print(bucket, table)
+
+	case "reset":
+		client, err := parseOne(flag.NewFlagSet("ratelimit reset", flag.ExitOnError), args, "client")
+		if err != nil {
+			return err
+		}
+		if err := c.client.do(http.MethodDelete, "/ratelimit/"+url.PathEscape(client), nil, nil); err != nil {
+			return err
+		}
+		return c.out.print(map[string]string{"client_id": client, "status": "reset"}, func(t *tabwriter.Writer) {
+			row(t, "CLIENT", "STATUS")
+			row(t, client, "reset")
+		})
+
+	default:
+		return fmt.Errorf("unknown ratelimit command %q", sub)
+	}
+}
+
+func (c *cli) config(sub string, args []string) error {
+	switch sub {
+	case "show":
+		var cfg map[string]any
+		if err := c.client.do(http.MethodGet, "/config", nil, &cfg); err != nil {
+			return err
+		}
+		// Конфигурация вложенная, в таблицу не ложится
+		return c.out.print(cfg, nil)
+
+	case "validate":
+		path, err := parseOne(flag.NewFlagSet("config validate", flag.ExitOnError), args, "file")
+		if err != nil {
+			return err
+		}
+		problems, err := validateFile(path)
+		if err != nil {
+			return err
+		}
+		result := map[string]any{"file": path, "valid": len(problems) == 0, "problems": problems}
+		if perr := c.out.print(result, func(t *tabwriter.Writer) {
+			if len(problems) == 0 {
+				row(t, path+": OK")
+			}
+			for _, p := range problems {
+				row(t, path+": "+p)
+			}
+		}); perr != nil {
+			return perr
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%s: %d problem(s) found", path, len(problems))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown config command %q", sub)
+	}
+}
+
+// parseOne разбирает флаги подкоманды и возвращает единственный позиционный аргумент.
+// Флаги допускаются как до, так и после аргумента.
+func parseOne(fs *flag.FlagSet, args []string, name string) (string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return "", err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != 1 {
+		return "", fmt.Errorf("%s: expected <%s>", fs.Name(), name)
+	}
+	return positional[0], nil
+}
+
+func since(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return time.Since(*t).Truncate(time.Second).String()
+}
+
+func until(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseOne(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "positional only", args: []string{"http://a"}, want: "http://a"},
+		{name: "flag before", args: []string{"-timeout", "5s", "http://a"}, want: "http://a", timeout: 5 * time.Second},
+		{name: "flag after", args: []string{"http://a", "-timeout", "5s"}, want: "http://a", timeout: 5 * time.Second},
+		{name: "flag with equals after", args: []string{"http://a", "-timeout=7s"}, want: "http://a", timeout: 7 * time.Second},
+		{name: "missing positional", args: []string{"-timeout", "5s"}, wantErr: true},
+		{name: "two positionals", args: []string{"http://a", "-timeout", "5s", "http://b"}, wantErr: true},
+		{name: "unknown flag", args: []string{"http://a", "-force"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("backends drain", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			timeout := fs.Duration("timeout", 0, "")
+
+			got, err := parseOne(fs, tc.args, "url")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want || *timeout != tc.timeout {
+				t.Errorf("got %q, timeout %s; want %q, %s", got, *timeout, tc.want, tc.timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+lbctl - клиент API администратора балансировщика.
+
+	lbctl [флаги] backends list
+	lbctl [флаги] backends drain|enable|disable <url>
+	lbctl [флаги] strategy show
+	lbctl [флаги] strategy set <name>
+	lbctl [флаги] ratelimit list
+	lbctl [флаги] ratelimit show|reset <client>
+	lbctl [флаги] config show
+	lbctl config validate <file>
+
+Адрес и токен берутся из флагов или переменных окружения LBCTL_ADDR и LBCTL_TOKEN.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+const usage = `Usage: lbctl [flags] <command>
+
+Commands:
+  backends list                        List backends with health, load and admin state
+  backends drain <url> [-timeout 30s]  Stop sending new requests to a backend
+  backends enable <url>                Return a backend to rotation
+  backends disable <url>               Take a backend out of rotation immediately
+  strategy show                        Show the current balancing strategy
+  strategy set <name>                  Switch the balancing strategy
+  ratelimit list                       List rate limiter buckets
+  ratelimit show <client>              Show the bucket of a client
+  ratelimit reset <client>             Reset the bucket of a client
+  config show                          Show the running config (secrets redacted)
+  config validate <file>               Validate a config file locally
+
+Flags:
+`
+
+func main() {
+	addr := flag.String("addr", envOr("LBCTL_ADDR", "http://localhost:9000"), "Admin API address")
+	token := flag.String("token", os.Getenv("LBCTL_TOKEN"), "Admin API bearer token")
+	output := flag.String("o", "table", "Output format: table | json")
+	timeout := flag.Duration("request-timeout", 10*time.Second, "Admin API request timeout")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *output != formatTable && *output != formatJSON {
+		fatalf("unknown output format %q", *output)
+	}
+
+	c := &cli{
+		client: newClient(*addr, *token, *timeout),
+		out:    newPrinter(os.Stdout, *output),
+	}
+	if err := c.run(flag.Args()); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+type cli struct {
+	client *client
+	out    *printer
+}
+
+func (c *cli) run(args []string) error {
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch cmd, sub, rest := args[0], args[1], args[2:]; cmd {
+	case "backends":
+		return c.backends(sub, rest)
+	case "strategy":
+		return c.strategy(sub, rest)
+	case "ratelimit":
+		return c.ratelimit(sub, rest)
+	case "config":
+		return c.config(sub, rest)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "lbctl: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Форматы вывода
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, format: format}
+}
+
+// print выводит v как JSON либо таблицу, которую строит table
+func (p *printer) print(v any, table func(t *tabwriter.Writer)) error {
+	if p.format == formatJSON || table == nil {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	t := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	table(t)
+	return t.Flush()
+}
+
+// row выводит строку таблицы
+func row(t *tabwriter.Writer, cols ...any) {
+	s := make([]string, len(cols))
+	for i, c := range cols {
+		s[i] = fmt.Sprint(c)
+	}
+	fmt.Fprintln(t, strings.Join(s, "\t"))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"load-balancer/internal/accesslog"
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/config"
+	"load-balancer/internal/health"
+	"load-balancer/internal/prettylog"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/server"
+	"load-balancer/internal/tracing"
+	"load-balancer/internal/upstreamtls"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// validateFile загружает конфигурацию так же, как балансировщик, и возвращает найденные проблемы.
+// Ошибка возвращается, только если файл не удалось прочитать или разобрать.
+func validateFile(path string) ([]string, error) {
+	cfg, err := config.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !slices.Contains(balancer.Strategies, cfg.Strategy) {
+		add("strategy: unknown strategy %q (available: %s)", cfg.Strategy, strings.Join(balancer.Strategies, ", "))
+	}
+
+	seen := make(map[string]bool)
+	for _, b := range append(append([]config.BackendConfig(nil), cfg.Backends...), cfg.Backup...) {
+		switch {
+		case b.URL == "":
+			add("backends: backend without url")
+		case seen[b.URL]:
+			add("backends: duplicate backend %q", b.URL)
+		}
+		seen[b.URL] = true
+		if b.Weight < 0 {
+			add("backends: %s: negative weight %d", b.URL, b.Weight)
+		}
+	}
+
+	validateProbe := func(field string, p config.HealthProbeConfig) {
+		check := health.Check{Type: strings.ToLower(p.Type)}
+		if _, err := health.NewProber(check, nil, nil); err != nil {
+			add("%s.type: %v", field, err)
+		}
+		if _, err := health.ParseStatusRanges(p.ExpectedStatus); err != nil {
+			add("%s.expected_status: %v", field, err)
+		}
+		if p.BodyRegex != "" {
+			if _, err := regexp.Compile(p.BodyRegex); err != nil {
+				add("%s.body_regex: %v", field, err)
+			}
+		}
+	}
+	validateProbe("health_check", cfg.HealthCheck.HealthProbeConfig)
+	for url, p := range cfg.HealthProbeOverrides() {
+		validateProbe("backends["+url+"].health_check", p)
+	}
+	if cfg.HealthCheck.TimeoutSeconds > cfg.HealthCheck.IntervalSeconds {
+		add("health_check: timeout_seconds (%s) exceeds interval_seconds (%s)",
+			cfg.HealthCheck.TimeoutSeconds, cfg.HealthCheck.IntervalSeconds)
+	}
+
+	validateUpstreamTLS := func(field string, t config.UpstreamTLSConfig) {
+		_, err := upstreamtls.New(upstreamtls.Config{
+			CAFile:             t.CAFile,
+			CertFile:           t.CertFile,
+			KeyFile:            t.KeyFile,
+			ServerName:         t.ServerName,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+		})
+		if err != nil {
+			add("%s: %v", field, err)
+		}
+	}
+	validateUpstreamTLS("proxy.tls", cfg.Proxy.TLS)
+	for url, t := range cfg.UpstreamTLSOverrides() {
+		validateUpstreamTLS("backends["+url+"].tls", t)
+	}
+
+	if _, err := server.NewRetryPolicy(
+		cfg.Retry.MaxAttempts,
+		cfg.Retry.RetryOn,
+		cfg.Retry.Methods,
+		cfg.Retry.MaxBodyBytes,
+		cfg.Retry.Budget,
+	); err != nil {
+		add("retry: %v", err)
+	}
+
+	if cfg.Tracing.Enabled && cfg.Tracing.Exporter != tracing.ExporterOTLP && cfg.Tracing.Exporter != tracing.ExporterStdout {
+		add("tracing.exporter: unknown exporter %q", cfg.Tracing.Exporter)
+	}
+	if _, err := prettylog.NewHandler(io.Discard, cfg.LogFormat, nil); err != nil {
+		add("log_format: %v", err)
+	}
+	if _, err := requestid.ParseTrusted(cfg.RequestID.TrustedSources); err != nil {
+		add("request_id.trusted_sources: %v", err)
+	}
+	if cfg.AccessLog.Enabled {
+		if _, err := accesslog.New(io.Discard, cfg.AccessLog.Format); err != nil {
+			add("access_log.format: %v", err)
+		}
+	}
+	if cfg.Admin.Enabled && cfg.Admin.Port == cfg.Server.Port {
+		add("admin: port %s is also used by server", cfg.Admin.Port)
+	}
+	if cfg.Metrics.Enabled {
+		switch cfg.Metrics.Port {
+		case cfg.Server.Port:
+			add("metrics: port %s is also used by server", cfg.Metrics.Port)
+		case cfg.Admin.Port:
+			if cfg.Admin.Enabled {
+				add("metrics: port %s is also used by admin", cfg.Metrics.Port)
+			}
+		}
+	}
+	if cfg.Server.TLS.Enabled {
+		validateTLS(cfg, add)
+	}
+
+	return problems, nil
+}
+
+func validateTLS(cfg *config.Config, add func(format string, args ...any)) {
+	t := cfg.Server.TLS
+	if t.Port == cfg.Server.Port {
+		add("server.tls: port %s is also used by server", t.Port)
+	}
+	if cfg.Admin.Enabled && cfg.Admin.Port == t.Port {
+		add("admin: port %s is also used by server.tls", cfg.Admin.Port)
+	}
+	if cfg.Metrics.Enabled && cfg.Metrics.Port == t.Port {
+		add("metrics: port %s is also used by server.tls", cfg.Metrics.Port)
+	}
+
+	files := make([]server.CertificateFiles, 0, len(t.Certificates))
+	for _, c := range t.Certificates {
+		files = append(files, server.CertificateFiles{CertFile: c.CertFile, KeyFile: c.KeyFile})
+	}
+	certs, err := server.LoadCertificates(files)
+	if err != nil {
+		add("server.tls.certificates: %v", err)
+	}
+	// Параметры рукопожатия проверяются и без сертификатов
+	_, err = server.NewTLSConfig(certs, server.TLSOptions{
+		MinVersion:   t.MinVersion,
+		CipherSuites: t.CipherSuites,
+		DisableHTTP2: t.DisableHTTP2,
+	})
+	if err != nil {
+		add("server.tls: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string // Подстрока ожидаемой проблемы; пусто - конфигурация корректна
+	}{
+		{
+			name: "valid",
+			yaml: "backends: [\"http://a:8081\", \"http://b:8082\"]\n",
+		},
+		{
+			name: "unknown strategy",
+			yaml: "strategy: fastest\nbackends: [\"http://a:8081\"]\n",
+			want: `strategy: unknown strategy "fastest"`,
+		},
+		{
+			name: "duplicate backend",
+			yaml: "backends: [\"http://a:8081\"]\nbackup_backends: [\"http://a:8081\"]\n",
+			want: `backends: duplicate backend "http://a:8081"`,
+		},
+		{
+			name: "bad body regex",
+			yaml: "backends: [\"http://a:8081\"]\nhealth_check:\n  body_regex: \"(\"\n",
+			want: "health_check.body_regex:",
+		},
+		{
+			name: "bad status range in backend override",
+			yaml: "backends:\n  - url: \"http://a:8081\"\n    health_check:\n      expected_status: [\"700\"]\n",
+			want: "backends[http://a:8081].health_check.expected_status:",
+		},
+		{
+			name: "admin port clash",
+			yaml: "backends: [\"http://a:8081\"]\nadmin:\n  enabled: true\n  port: \"8080\"\n",
+			want: "admin: port 8080 is also used by server",
+		},
+		{
+			name: "metrics port clash",
+			yaml: "backends: [\"http://a:8081\"]\nmetrics:\n  enabled: true\n  port: \"8080\"\n",
+			want: "metrics: port 8080 is also used by server",
+		},
+		{
+			name: "tls without certificates",
+			yaml: "backends: [\"http://a:8081\"]\nserver:\n  tls:\n    enabled: true\n",
+			want: "server.tls.certificates: no certificates configured",
+		},
+		{
+			name: "tls unknown version",
+			yaml: "backends: [\"http://a:8081\"]\nserver:\n  tls:\n    enabled: true\n    min_version: \"1.9\"\n",
+			want: `server.tls: unknown TLS version "1.9"`,
+		},
+		{
+			name: "upstream tls missing ca",
+			yaml: "backends: [\"https://a:8443\"]\nproxy:\n  tls:\n    ca_file: /nonexistent/ca.pem\n",
+			want: "proxy.tls: CA bundle:",
+		},
+		{
+			name: "bad trusted sources",
+			yaml: "backends: [\"http://a:8081\"]\nrequest_id:\n  trusted_sources: [\"not-an-ip\"]\n",
+			want: "request_id.trusted_sources:",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			problems, err := validateFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.want == "" {
+				if len(problems) != 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			for _, p := range problems {
+				if strings.Contains(p, tc.want) {
+					return
+				}
+			}
+			t.Errorf("problems %q do not contain %q", problems, tc.want)
+		})
+	}
+}
+
+func TestValidateFileParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("backends: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validateFile(path); err == nil {
+		t.Error("expected error for malformed YAML")
+	}
+	if _, err := validateFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -207,14 +207,14 @@ func main() {
 	appCtx, appCancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup // Для ожидания завершения всех серверов
 
-	for _, backend := range cfg.Backends {
-		u, err := url.Parse(backend)
+	for _, backend := range cfg.BackendList() {
+		u, err := url.Parse(backend.URL)
 		if err != nil {
-			slog.Error("Failed to parse backend URL for mock server", slog.String("URL", backend), slog.String("error", err.Error()))
+			slog.Error("Failed to parse backend URL for mock server", slog.String("URL", backend.URL), slog.String("error", err.Error()))
 			continue
 		}
 		wg.Add(1)
-		go startServer(appCtx, u.Port(), backend, &wg)
+		go startServer(appCtx, u.Port(), backend.URL, &wg)
 	}
 
 	// Ожидание сигнала для завершения
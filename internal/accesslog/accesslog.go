@@ -0,0 +1,82 @@
+/*
+Пакет accesslog реализует журнал запросов:
+- Одна строка на запрос в формате JSON, Apache combined или logfmt
+- Данные о бэкенде, задержках и повторах от обработчика через контекст запроса
+- Запись в файл с переоткрытием по сигналу (для logrotate)
+*/
+
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Форматы журнала
+const (
+	FormatJSON     = "json"
+	FormatCombined = "combined"
+	FormatLogfmt   = "logfmt"
+)
+
+// Entry запись журнала об одном запросе
+type Entry struct {
+	Time            time.Time
+	ClientKey       string // Ключ клиента (userkey), по умолчанию IP
+	Method          string
+	URI             string // Путь с параметрами запроса
+	Proto           string
+	Status          int
+	Bytes           int64 // Байт тела ответа
+	Referer         string
+	UserAgent       string
+	Upstream        string        // Бэкенд последней попытки; пусто - запрос не проксировался
+	UpstreamLatency time.Duration // Время до заголовков ответа бэкенда в последней попытке
+	Latency         time.Duration // Полное время обработки запроса
+	Retries         int
+	RequestID       string
+}
+
+// Logger пишет записи журнала в одном формате
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format func(*Entry) []byte
+}
+
+// New создает журнал. Неизвестный формат - ошибка.
+func New(w io.Writer, format string) (*Logger, error) {
+	f, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown access log format %q", format)
+	}
+	return &Logger{w: w, format: f}, nil
+}
+
+// Log записывает запись одной строкой
+func (l *Logger) Log(e *Entry) {
+	line := l.format(e)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(line); err != nil {
+		slog.Error("AccessLog: write failed", slog.String("error", err.Error()))
+	}
+}
+
+type entryKey struct{}
+
+// FromContext возвращает запись текущего запроса, чтобы обработчик дополнил ее
+// данными о бэкенде. nil, если журнал выключен.
+func FromContext(ctx context.Context) *Entry {
+	e, _ := ctx.Value(entryKey{}).(*Entry)
+	return e
+}
+
+func withEntry(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
@@ -0,0 +1,121 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry() *Entry {
+	return &Entry{
+		Time:            time.Date(2024, 3, 5, 14, 2, 3, 0, time.UTC),
+		ClientKey:       "10.0.0.1",
+		Method:          "GET",
+		URI:             "/api?q=1",
+		Proto:           "HTTP/1.1",
+		Status:          200,
+		Bytes:           512,
+		UserAgent:       `curl/8.0 "test"`,
+		Upstream:        "http://b:8081",
+		UpstreamLatency: 12 * time.Millisecond,
+		Latency:         15 * time.Millisecond,
+		Retries:         1,
+		RequestID:       "abc",
+	}
+}
+
+func TestFormats(t *testing.T) {
+	e := testEntry()
+
+	combined := string(formatCombined(e))
+	want := `10.0.0.1 - - [05/Mar/2024:14:02:03 +0000] "GET /api?q=1 HTTP/1.1" 200 512 "-" "curl/8.0 \"test\"" ` +
+		"upstream=http://b:8081 upstream_latency=0.012 latency=0.015 retries=1 request_id=abc\n"
+	if combined != want {
+		t.Errorf("combined:\n got %q\nwant %q", combined, want)
+	}
+
+	logfmt := string(formatLogfmt(e))
+	for _, part := range []string{
+		"client=10.0.0.1", `uri="/api?q=1"`, "status=200", `referer=""`, `user_agent="curl/8.0 \"test\""`,
+		"upstream=http://b:8081", "upstream_latency=0.012", "retries=1", "request_id=abc",
+	} {
+		if !strings.Contains(logfmt, part) {
+			t.Errorf("logfmt %q does not contain %q", logfmt, part)
+		}
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(formatJSON(e), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["upstream"] != "http://b:8081" || decoded["latency"] != 0.015 || decoded["retries"] != 1.0 {
+		t.Errorf("json = %v", decoded)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := FromContext(r.Context())
+		e.Upstream = "http://a"
+		e.Retries = 2
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("oops"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/x", nil)
+	req.Header.Set("X-Forwarded-For", "192.168.1.100")
+	req.Header.Set("X-Request-ID", "rid-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var got jsonEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ClientKey != "192.168.1.100" || got.Status != 502 || got.Bytes != 4 ||
+		got.Upstream != "http://a" || got.Retries != 2 || got.RequestID != "rid-1" || got.Method != "POST" {
+		t.Errorf("entry = %+v", got)
+	}
+
+	if _, err := New(&buf, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write([]byte("first\n"))
+	// logrotate переименовывает файл, затем просит переоткрыть его
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte("second\n"))
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if string(rotated) != "first\n" || string(current) != "second\n" {
+		t.Errorf("rotated = %q, current = %q", rotated, current)
+	}
+}
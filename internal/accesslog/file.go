@@ -0,0 +1,80 @@
+package accesslog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// File файл журнала, который можно переоткрыть после ротации
+type File struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenFile открывает файл на дозапись, создавая его при необходимости
+func OpenFile(path string) (*File, error) {
+	f := &File{path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Write(p)
+}
+
+// Reopen закрывает текущий файл и открывает файл по тому же пути.
+// При ошибке запись продолжается в прежний файл.
+func (f *File) Reopen() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	old := f.f
+	f.f = file
+	f.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Close()
+}
+
+// ReopenOnSignal переоткрывает файл при получении сигналов sig до отмены ctx.
+// Без сигналов ничего не делает (signal.Notify без сигналов подписывает на все).
+func (f *File) ReopenOnSignal(ctx context.Context, sig ...os.Signal) {
+	if len(sig) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case s := <-ch:
+			if err := f.Reopen(); err != nil {
+				slog.Error("AccessLog: reopen failed", slog.String("path", f.path), slog.String("error", err.Error()))
+				continue
+			}
+			slog.Info("AccessLog: file reopened", slog.String("path", f.path), slog.String("signal", s.String()))
+		}
+	}
+}
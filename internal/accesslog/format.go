@@ -0,0 +1,140 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var formats = map[string]func(*Entry) []byte{
+	FormatJSON:     formatJSON,
+	FormatCombined: formatCombined,
+	FormatLogfmt:   formatLogfmt,
+}
+
+type jsonEntry struct {
+	Time            string  `json:"time"`
+	ClientKey       string  `json:"client"`
+	Method          string  `json:"method"`
+	URI             string  `json:"uri"`
+	Proto           string  `json:"proto"`
+	Status          int     `json:"status"`
+	Bytes           int64   `json:"bytes"`
+	Referer         string  `json:"referer,omitempty"`
+	UserAgent       string  `json:"user_agent,omitempty"`
+	Upstream        string  `json:"upstream,omitempty"`
+	UpstreamLatency float64 `json:"upstream_latency"` // Секунды
+	Latency         float64 `json:"latency"`          // Секунды
+	Retries         int     `json:"retries"`
+	RequestID       string  `json:"request_id,omitempty"`
+}
+
+func formatJSON(e *Entry) []byte {
+	b, _ := json.Marshal(jsonEntry{
+		Time:            e.Time.Format(time.RFC3339Nano),
+		ClientKey:       e.ClientKey,
+		Method:          e.Method,
+		URI:             e.URI,
+		Proto:           e.Proto,
+		Status:          e.Status,
+		Bytes:           e.Bytes,
+		Referer:         e.Referer,
+		UserAgent:       e.UserAgent,
+		Upstream:        e.Upstream,
+		UpstreamLatency: e.UpstreamLatency.Seconds(),
+		Latency:         e.Latency.Seconds(),
+		Retries:         e.Retries,
+		RequestID:       e.RequestID,
+	})
+	return append(b, '\n')
+}
+
+// formatCombined формат Apache combined; данные балансировщика добавляются в конце строки
+// в виде key=value, как в log_format nginx
+func formatCombined(e *Entry) []byte {
+	var b strings.Builder
+	b.WriteString(dash(e.ClientKey))
+	b.WriteString(" - - [")
+	b.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString(`] "`)
+	b.WriteString(escape(e.Method + " " + e.URI + " " + e.Proto))
+	b.WriteString(`" `)
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteByte(' ')
+	if e.Bytes > 0 {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	} else {
+		b.WriteByte('-')
+	}
+	b.WriteString(` "`)
+	b.WriteString(escape(dash(e.Referer)))
+	b.WriteString(`" "`)
+	b.WriteString(escape(dash(e.UserAgent)))
+	b.WriteString(`" upstream=`)
+	b.WriteString(dash(e.Upstream))
+	b.WriteString(" upstream_latency=")
+	b.WriteString(seconds(e.UpstreamLatency))
+	b.WriteString(" latency=")
+	b.WriteString(seconds(e.Latency))
+	b.WriteString(" retries=")
+	b.WriteString(strconv.Itoa(e.Retries))
+	b.WriteString(" request_id=")
+	b.WriteString(dash(e.RequestID))
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+func formatLogfmt(e *Entry) []byte {
+	var b strings.Builder
+	kv := func(key, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(value))
+	}
+
+	kv("time", e.Time.Format(time.RFC3339Nano))
+	kv("client", e.ClientKey)
+	kv("method", e.Method)
+	kv("uri", e.URI)
+	kv("proto", e.Proto)
+	kv("status", strconv.Itoa(e.Status))
+	kv("bytes", strconv.FormatInt(e.Bytes, 10))
+	kv("referer", e.Referer)
+	kv("user_agent", e.UserAgent)
+	kv("upstream", e.Upstream)
+	kv("upstream_latency", seconds(e.UpstreamLatency))
+	kv("latency", seconds(e.Latency))
+	kv("retries", strconv.Itoa(e.Retries))
+	kv("request_id", e.RequestID)
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+// logfmtValue берет в кавычки пустые значения и значения с пробелами, кавычками и '='
+func logfmtValue(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \"=\t\n\r\\") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// escape экранирует кавычки и управляющие символы внутри полей в кавычках
+func escape(s string) string {
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
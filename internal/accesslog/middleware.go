@@ -0,0 +1,64 @@
+package accesslog
+
+import (
+	"load-balancer/internal/utils/userkey"
+	"net/http"
+	"time"
+)
+
+// Middleware пишет строку журнала после обработки каждого запроса
+func Middleware(l *Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		e := &Entry{
+			Time:      start,
+			Method:    r.Method,
+			URI:       r.URL.RequestURI(),
+			Proto:     r.Proto,
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+			RequestID: r.Header.Get("X-Request-ID"),
+		}
+		cip, _ := userkey.ReqToIP(r)
+		e.ClientKey = cip.Value()
+
+		rw := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r.WithContext(withEntry(r.Context(), e)))
+
+		e.Status = rw.status
+		if e.Status == 0 {
+			e.Status = http.StatusOK
+		}
+		e.Bytes = rw.bytes
+		e.Latency = time.Since(start)
+		l.Log(e)
+	})
+}
+
+// responseWriter запоминает код ответа и размер тела
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap нужен http.ResponseController (Flush при проксировании потоковых ответов)
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,294 @@
+/*
+Пакет admin реализует HTTP API администратора на отдельном порту:
+- Список бэкендов с состоянием проверок, весом, активными запросами и circuit breaker
+- Вывод бэкенда из ротации (drain), выключение и включение
+- Переключение стратегии балансировки
+- Просмотр и сброс бакетов rate limiter
+- Просмотр действующей конфигурации
+*/
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"load-balancer/internal/apperror"
+	"load-balancer/internal/backend"
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/breaker"
+	"load-balancer/internal/config"
+	"load-balancer/internal/health"
+	"load-balancer/internal/outlier"
+	"load-balancer/internal/ratelimiter"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Server обработчики API администратора. Необязательные компоненты (Breakers, Outliers,
+// Health, RateLimiter) могут быть nil, если выключены.
+type Server struct {
+	Balancer     *balancer.AtomicBalancer
+	Strategy     *balancer.StrategySwitcher
+	Maintenance  *balancer.Maintenance
+	Health       *health.Checker
+	Breakers     *breaker.Set
+	Outliers     *outlier.Detector
+	RateLimiter  *ratelimiter.Limiter
+	Config       func() *config.Config
+	Token        string        // Bearer-токен; пусто - без авторизации
+	DrainTimeout time.Duration // Таймаут вывода по умолчанию
+}
+
+// BackendInfo состояние бэкенда
+type BackendInfo struct {
+	URL          string     `json:"url"`
+	Priority     string     `json:"priority"`
+	Weight       int        `json:"weight"`
+	Health       string     `json:"health"`
+	HealthSince  *time.Time `json:"health_since,omitempty"`
+	HealthReason string     `json:"health_reason,omitempty"`
+	InFlight     int64      `json:"in_flight"`
+	AdminState   string     `json:"admin_state"`
+	Circuit      string     `json:"circuit,omitempty"`
+	EjectedUntil *time.Time `json:"ejected_until,omitempty"`
+	Serving      bool       `json:"serving"` // Бэкенд сейчас получает трафик
+}
+
+// BackendRequest тело запросов drain/enable/disable
+type BackendRequest struct {
+	URL     string `json:"url"`
+	Timeout string `json:"timeout,omitempty"` // Только для drain, например "30s"
+}
+
+// StrategyRequest тело запроса смены стратегии
+type StrategyRequest struct {
+	Strategy string `json:"strategy"`
+}
+
+// Handler возвращает маршруты API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /backends", s.listBackends)
+	mux.HandleFunc("POST /backends/drain", s.drainBackend)
+	mux.HandleFunc("POST /backends/enable", s.enableBackend)
+	mux.HandleFunc("POST /backends/disable", s.disableBackend)
+	mux.HandleFunc("GET /strategy", s.getStrategy)
+	mux.HandleFunc("PUT /strategy", s.setStrategy)
+	mux.HandleFunc("GET /ratelimit", s.listBuckets)
+	mux.HandleFunc("GET /ratelimit/{client}", s.getBucket)
+	mux.HandleFunc("DELETE /ratelimit/{client}", s.resetBucket)
+	mux.HandleFunc("GET /config", s.getConfig)
+
+	if s.Token == "" {
+		return mux
+	}
+	return s.auth(mux)
+}
+
+func (s *Server) auth(next http.Handler) http.Handler {
+	expected := []byte("Bearer " + s.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, apperror.New("Invalid or missing admin token", http.StatusUnauthorized))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listBackends(w http.ResponseWriter, r *http.Request) {
+	serving := s.Balancer.Candidates()
+	stats := s.Balancer.Stats()
+
+	list := s.Config().BackendList()
+	infos := make([]BackendInfo, 0, len(list))
+	for _, b := range list {
+		info := BackendInfo{
+			URL:        b.URL,
+			Priority:   "primary",
+			Weight:     b.Weight,
+			Health:     health.StateUnknown.String(),
+			InFlight:   stats.InFlight(b.URL),
+			AdminState: s.Maintenance.State(b.URL).String(),
+			Serving:    slices.Contains(serving, b.URL),
+		}
+		if b.Priority == backend.PriorityBackup {
+			info.Priority = "backup"
+		}
+		if s.Health != nil {
+			if st, ok := s.Health.Status(b.URL); ok {
+				info.Health = st.State.String()
+				info.HealthReason = st.Reason
+				if !st.Since.IsZero() {
+					info.HealthSince = &st.Since
+				}
+			}
+		}
+		if s.Breakers != nil {
+			info.Circuit = s.Breakers.State(b.URL).String()
+		}
+		if s.Outliers != nil {
+			if until, ok := s.Outliers.Ejected(b.URL); ok {
+				info.EjectedUntil = &until
+			}
+		}
+		infos = append(infos, info)
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) drainBackend(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.backendRequest(w, r)
+	if !ok {
+		return
+	}
+
+	timeout := s.DrainTimeout
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 {
+			writeError(w, apperror.New("Invalid timeout", http.StatusBadRequest))
+			return
+		}
+		timeout = d
+	}
+
+	s.Maintenance.Drain(req.URL, timeout)
+	slog.Info("Admin: backend drain requested", slog.String("backend", req.URL))
+	s.writeState(w, http.StatusAccepted, req.URL)
+}
+
+func (s *Server) enableBackend(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.backendRequest(w, r)
+	if !ok {
+		return
+	}
+	s.Maintenance.Enable(req.URL)
+	slog.Info("Admin: backend enabled", slog.String("backend", req.URL))
+	s.writeState(w, http.StatusOK, req.URL)
+}
+
+func (s *Server) disableBackend(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.backendRequest(w, r)
+	if !ok {
+		return
+	}
+	s.Maintenance.Disable(req.URL)
+	slog.Info("Admin: backend disabled", slog.String("backend", req.URL))
+	s.writeState(w, http.StatusOK, req.URL)
+}
+
+// backendRequest разбирает тело запроса и проверяет, что бэкенд есть в конфигурации
+func (s *Server) backendRequest(w http.ResponseWriter, r *http.Request) (BackendRequest, bool) {
+	var req BackendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		writeError(w, apperror.New("Request body must be JSON with \"url\"", http.StatusBadRequest))
+		return req, false
+	}
+	if !slices.Contains(backend.URLs(s.Config().BackendList()), req.URL) {
+		writeError(w, apperror.New("Unknown backend", http.StatusNotFound))
+		return req, false
+	}
+	return req, true
+}
+
+func (s *Server) writeState(w http.ResponseWriter, code int, url string) {
+	writeJSON(w, code, map[string]any{
+		"url":         url,
+		"admin_state": s.Maintenance.State(url).String(),
+		"in_flight":   s.Balancer.Stats().InFlight(url),
+	})
+}
+
+func (s *Server) getStrategy(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"strategy":  s.Strategy.Name(),
+		"available": balancer.Strategies,
+	})
+}
+
+func (s *Server) setStrategy(w http.ResponseWriter, r *http.Request) {
+	var req StrategyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.New("Request body must be JSON with \"strategy\"", http.StatusBadRequest))
+		return
+	}
+	if err := s.Strategy.Switch(req.Strategy); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, balancer.ErrUnknownStrategy) {
+			code = http.StatusBadRequest
+		}
+		writeError(w, apperror.New(err.Error(), code))
+		return
+	}
+	slog.Info("Admin: strategy set", slog.String("strategy", req.Strategy))
+	s.getStrategy(w, r)
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	if !s.rateLimiterEnabled(w) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.RateLimiter.Buckets())
+}
+
+func (s *Server) getBucket(w http.ResponseWriter, r *http.Request) {
+	if !s.rateLimiterEnabled(w) {
+		return
+	}
+	b, ok := s.RateLimiter.Bucket(r.PathValue("client"))
+	if !ok {
+		writeError(w, apperror.New("No bucket for client", http.StatusNotFound))
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (s *Server) resetBucket(w http.ResponseWriter, r *http.Request) {
+	if !s.rateLimiterEnabled(w) {
+		return
+	}
+	client := r.PathValue("client")
+	if !s.RateLimiter.Reset(client) {
+		writeError(w, apperror.New("No bucket for client", http.StatusNotFound))
+		return
+	}
+	slog.Info("Admin: rate limiter bucket reset", slog.String("client_id", client))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) rateLimiterEnabled(w http.ResponseWriter) bool {
+	if s.RateLimiter == nil {
+		writeError(w, apperror.New("Rate limiter is disabled", http.StatusNotFound))
+		return false
+	}
+	return true
+}
+
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.Redacted(s.Config())
+	if err != nil {
+		writeError(w, apperror.New(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Error("Admin: failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+func writeError(w http.ResponseWriter, e *apperror.AppError) {
+	writeJSON(w, e.Code, e)
+}
@@ -0,0 +1,169 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"load-balancer/internal/admin"
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/config"
+	"load-balancer/internal/ratelimiter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *balancer.AtomicBalancer) {
+	t.Helper()
+
+	cfg := &config.Config{
+		Strategy: "round-robin",
+		Backends: []config.BackendConfig{{URL: "http://a", Weight: 1}, {URL: "http://b", Weight: 2}},
+		Sticky:   config.StickyConfig{Secret: "s3cret"},
+	}
+	cfg.HealthCheck.Headers = map[string]string{"Authorization": "Bearer global"}
+	cfg.Backends[1].HealthCheck = &config.HealthProbeConfig{Headers: map[string]string{"Authorization": "Bearer b"}}
+
+	stats := balancer.NewStats()
+	factory := balancer.NewStrategyFactory(stats)
+	ab := balancer.NewAtomicBalancer(factory.Create(cfg.Strategy, nil), stats)
+	maintenance := balancer.NewMaintenance(stats, ab.Refresh)
+	ab.AddFilter(maintenance)
+	ab.Update(cfg.BackendList())
+
+	rl := ratelimiter.NewLimiter(5, 1, nil)
+	rl.Allow("10.0.0.1")
+
+	a := &admin.Server{
+		Balancer:     ab,
+		Strategy:     balancer.NewStrategySwitcher(ab, factory, cfg.Strategy),
+		Maintenance:  maintenance,
+		RateLimiter:  rl,
+		Config:       func() *config.Config { return cfg },
+		Token:        "t0ken",
+		DrainTimeout: time.Second,
+	}
+	srv := httptest.NewServer(a.Handler())
+	t.Cleanup(srv.Close)
+	return srv, ab
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+
+	req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer t0ken")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAuth(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp, err := srv.Client().Get(srv.URL + "/backends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestBackends(t *testing.T) {
+	srv, ab := newTestServer(t)
+
+	var list []admin.BackendInfo
+	if code := do(t, srv, http.MethodGet, "/backends", "", &list); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(list) != 2 || list[1].Weight != 2 || !list[0].Serving || list[0].AdminState != "enabled" {
+		t.Fatalf("backends = %+v", list)
+	}
+
+	if code := do(t, srv, http.MethodPost, "/backends/drain", `{"url":"http://x"}`, nil); code != http.StatusNotFound {
+		t.Errorf("drain unknown backend: status = %d, want 404", code)
+	}
+
+	var state map[string]any
+	if code := do(t, srv, http.MethodPost, "/backends/drain", `{"url":"http://a"}`, &state); code != http.StatusAccepted {
+		t.Fatalf("drain: status = %d", code)
+	}
+	if state["admin_state"] != "draining" {
+		t.Errorf("state = %v", state)
+	}
+	if got := ab.Candidates(); len(got) != 1 || got[0] != "http://b" {
+		t.Errorf("candidates = %v, want only http://b", got)
+	}
+
+	do(t, srv, http.MethodPost, "/backends/enable", `{"url":"http://a"}`, &state)
+	if state["admin_state"] != "enabled" || len(ab.Candidates()) != 2 {
+		t.Errorf("enable: state = %v, candidates = %v", state, ab.Candidates())
+	}
+}
+
+func TestStrategy(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	if code := do(t, srv, http.MethodPut, "/strategy", `{"strategy":"nope"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("unknown strategy: status = %d, want 400", code)
+	}
+
+	var resp struct{ Strategy string }
+	if code := do(t, srv, http.MethodPut, "/strategy", `{"strategy":"p2c-ewma"}`, &resp); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if resp.Strategy != "p2c-ewma" {
+		t.Errorf("strategy = %q", resp.Strategy)
+	}
+}
+
+func TestRateLimitAndConfig(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	var bucket ratelimiter.BucketState
+	if code := do(t, srv, http.MethodGet, "/ratelimit/10.0.0.1", "", &bucket); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if bucket.Capacity != 5 || bucket.Tokens != 4 {
+		t.Errorf("bucket = %+v", bucket)
+	}
+	if code := do(t, srv, http.MethodDelete, "/ratelimit/10.0.0.1", "", nil); code != http.StatusNoContent {
+		t.Errorf("reset: status = %d", code)
+	}
+	if code := do(t, srv, http.MethodGet, "/ratelimit/10.0.0.1", "", nil); code != http.StatusNotFound {
+		t.Errorf("after reset: status = %d, want 404", code)
+	}
+
+	var cfg map[string]any
+	do(t, srv, http.MethodGet, "/config", "", &cfg)
+	sticky, _ := cfg["sticky_session"].(map[string]any)
+	if sticky["secret"] == "s3cret" {
+		t.Error("secret is not redacted")
+	}
+	if cfg["strategy"] != "round-robin" {
+		t.Errorf("config strategy = %v", cfg["strategy"])
+	}
+	hc, _ := cfg["health_check"].(map[string]any)
+	if headers, _ := hc["headers"].(map[string]any); headers["Authorization"] != "<redacted>" {
+		t.Errorf("health_check.headers not redacted: %v", hc["headers"])
+	}
+	backends, _ := cfg["backends"].([]any)
+	if len(backends) != 2 {
+		t.Fatalf("backends = %v", cfg["backends"])
+	}
+	b, _ := backends[1].(map[string]any)
+	bhc, _ := b["health_check"].(map[string]any)
+	if headers, _ := bhc["headers"].(map[string]any); headers["Authorization"] != "<redacted>" {
+		t.Errorf("backends[1].health_check.headers not redacted: %v", bhc["headers"])
+	}
+}
@@ -3,14 +3,22 @@ package apperror
 import "net/http"
 
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
+// WithRequestID возвращает копию ошибки с ID запроса (готовые ошибки общие для всех запросов)
+func (e *AppError) WithRequestID(id string) *AppError {
+	c := *e
+	c.RequestID = id
+	return &c
+}
+
 func New(message string, code int) *AppError {
 	return &AppError{
 		Code:    code,
@@ -0,0 +1,30 @@
+/*
+Пакет backend описывает бэкенд-сервер в том виде,
+в котором он передается между health checker'ом и балансировщиком
+*/
+
+package backend
+
+// DefaultWeight вес бэкенда, если он не указан в конфигурации
+const DefaultWeight = 1
+
+// Уровни приоритета: чем меньше значение, тем выше приоритет
+const (
+	PriorityPrimary = 0
+	PriorityBackup  = 1
+)
+
+type Backend struct {
+	URL      string
+	Weight   int
+	Priority int // Уровень приоритета (PriorityPrimary, PriorityBackup)
+}
+
+// URLs возвращает адреса бэкендов в исходном порядке
+func URLs(backends []Backend) []string {
+	urls := make([]string, 0, len(backends))
+	for _, b := range backends {
+		urls = append(urls, b.URL)
+	}
+	return urls
+}
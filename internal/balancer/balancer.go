@@ -8,49 +8,329 @@
 package balancer
 
 import (
+	"errors"
+	"load-balancer/internal/backend"
+	"slices"
+	"strconv"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// Balancer выбирает бэкенд для очередного запроса.
+// key - ключ клиента (значение userkey.Param), его используют стратегии с привязкой клиента к бэкенду;
+// остальные стратегии его игнорируют.
 type Balancer interface {
-	Next() (string, error)
-	Update([]string)
+	Next(key string) (string, error)
+	Update([]backend.Backend)
 }
 
-// AtomicBalancer обеспечивает атомарную замену стратегий
+// Picker реализуют стратегии, которые умеют выбирать только среди разрешенных бэкендов.
+// AtomicBalancer передает в allow ограничения конкретного запроса, и стратегия выбирает
+// с учетом своей логики (нагрузка, веса, ключ) из оставшихся, а не предлагает отклоненный снова.
+// allow == nil - разрешены все. Встроенные стратегии реализуют Picker.
+type Picker interface {
+	Pick(key string, allow func(backend string) bool) (string, error)
+}
+
+// TrackingBalancer дополнительно получает уведомление о завершении запроса,
+// выданного через Next, вместе с его итогом (задержка, статус, ошибка).
+// Каждому успешному Next должен соответствовать ровно один Done.
+type TrackingBalancer interface {
+	Balancer
+	Done(backend string, res Result)
+	// NextExcluding как Next, но не выдает бэкенды из exclude (для повторных попыток)
+	NextExcluding(key string, exclude []string) (string, error)
+	// Pin выдает указанный бэкенд в обход стратегии, если он сейчас среди кандидатов.
+	// При успехе запрос учитывается так же, как после Next.
+	Pin(backend string) bool
+}
+
+// AtomicBalancer обеспечивает атомарную замену стратегий,
+// учет активных запросов и задержек по бэкендам,
+// выбор кандидатов из живых бэкендов (фильтры, уровни приоритета) и slow start.
 type AtomicBalancer struct {
-	value atomic.Value
+	value     atomic.Value
+	stats     *Stats
+	slowStart atomic.Pointer[SlowStart]
+	filters   atomic.Pointer[[]Filter]
+
+	mu           sync.Mutex           // Сериализует пересчет кандидатов
+	healthy      []backend.Backend    // Последний список живых бэкендов от health checker
+	healthySince map[string]time.Time // Когда бэкенд стал живым; нулевое время - без прогрева
+	initialized  bool                 // Первый список живых принимается без прогрева
+	minHealthy   int                  // Минимум живых бэкендов, при котором уровень приоритета обслуживает трафик
+
+	candidates atomic.Pointer[map[string]time.Time] // Бэкенды, переданные стратегии -> начало прогрева
 }
 
-func NewAtomicBalancer(initial Balancer) *AtomicBalancer {
+func NewAtomicBalancer(initial Balancer, stats *Stats) *AtomicBalancer {
 	if initial == nil {
 		panic("nil balancer")
 	}
+	if stats == nil {
+		stats = NewStats()
+	}
 
-	ab := &AtomicBalancer{}
-	ab.value.Store(initial)
+	ab := &AtomicBalancer{stats: stats, minHealthy: 1}
+	ab.Store(initial)
+	ab.slowStart.Store(&SlowStart{})
+	ab.filters.Store(&[]Filter{})
+	ab.candidates.Store(&map[string]time.Time{})
 	return ab
 }
 
-// Next делегирует вызов текущей стратегии
-func (ab *AtomicBalancer) Next() (string, error) {
-	return ab.Load().Next()
+// Next делегирует вызов текущей стратегии и учитывает запрос как активный.
+// Прогревающийся бэкенд допускается к выбору с вероятностью по SlowStart.
+func (ab *AtomicBalancer) Next(key string) (string, error) {
+	return ab.next(key, nil)
+}
+
+func (ab *AtomicBalancer) NextExcluding(key string, exclude []string) (string, error) {
+	return ab.next(key, exclude)
+}
+
+func (ab *AtomicBalancer) next(key string, exclude []string) (string, error) {
+	strategy := picker(ab.Load())
+	filters := *ab.filters.Load()
+	// Прогревающиеся бэкенды, не допущенные к этому выбору
+	cold := ab.slowStart.Load().cold(*ab.candidates.Load())
+	// Бэкенды, выбранные стратегией, но отклоненные Admit
+	var rejected []string
+
+	// Стратегия выбирает только из допущенных: иначе least-connections раз за разом
+	// предлагал бы наименее загруженный бэкенд, даже если он исключен или отклонен
+	allow := func(b string) bool {
+		return !slices.Contains(exclude, b) && !slices.Contains(rejected, b) &&
+			!slices.Contains(cold, b) && !excluded(filters, b)
+	}
+
+	for {
+		backend, err := strategy.Pick(key, allow)
+		if errors.Is(err, ErrNoHealthyBackends) && len(cold) > 0 {
+			// Прогрев только снижает долю трафика: если остались одни прогревающиеся, берем их
+			cold = nil
+			continue
+		}
+		if errors.Is(err, ErrNoHealthyBackends) && len(exclude) > 0 {
+			return "", ErrNoOtherBackends
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// Admit вызывается только для выбранного бэкенда: он может занять слот (например, пробный запрос)
+		if admit(filters, backend) {
+			ab.stats.Acquire(backend)
+			return backend, nil
+		}
+		rejected = append(rejected, backend)
+	}
+}
+
+func (ab *AtomicBalancer) Pin(backend string) bool {
+	if _, ok := (*ab.candidates.Load())[backend]; !ok {
+		return false
+	}
+	if !admit(*ab.filters.Load(), backend) {
+		return false
+	}
+
+	ab.stats.Acquire(backend)
+	return true
+}
+
+// Done сообщает о завершении запроса, полученного через Next, и передает итог фильтрам
+func (ab *AtomicBalancer) Done(backend string, res Result) {
+	ab.stats.Observe(backend, res.RTT)
+	ab.stats.Release(backend)
+	for _, f := range *ab.filters.Load() {
+		f.Observe(backend, res)
+	}
+}
+
+// AddFilter добавляет фильтр кандидатов
+func (ab *AtomicBalancer) AddFilter(f Filter) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	filters := append(append([]Filter(nil), *ab.filters.Load()...), f)
+	ab.filters.Store(&filters)
+	ab.refreshLocked()
+}
+
+// Refresh пересчитывает кандидатов. Вызывается фильтрами при изменении их решения.
+func (ab *AtomicBalancer) Refresh() {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	ab.refreshLocked()
+}
+
+// Candidates возвращает бэкенды, которые сейчас получают трафик
+func (ab *AtomicBalancer) Candidates() []string {
+	set := *ab.candidates.Load()
+	list := make([]string, 0, len(set))
+	for b := range set {
+		list = append(list, b)
+	}
+	slices.Sort(list)
+	return list
+}
+
+// Stats возвращает статистику по бэкендам
+func (ab *AtomicBalancer) Stats() *Stats {
+	return ab.stats
+}
+
+// Update принимает список живых бэкендов и передает стратегии только кандидатов
+func (ab *AtomicBalancer) Update(backends []backend.Backend) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	now := time.Now()
+	since := make(map[string]time.Time, len(backends))
+	for _, b := range backends {
+		switch t, ok := ab.healthySince[b.URL]; {
+		case ok:
+			since[b.URL] = t
+		case ab.initialized:
+			since[b.URL] = now // Бэкенд только что стал живым: начинаем прогрев
+		default:
+			since[b.URL] = time.Time{}
+		}
+	}
+	ab.healthySince = since
+	ab.initialized = true
+
+	ab.healthy = append([]backend.Backend(nil), backends...)
+	ab.refreshLocked()
 }
 
-func (ab *AtomicBalancer) Update(backends []string) {
-	ab.Load().Update(backends)
+// SetSlowStart задает параметры прогрева восстановившихся бэкендов
+func (ab *AtomicBalancer) SetSlowStart(s SlowStart) {
+	ab.slowStart.Store(&s)
 }
 
+// SetMinHealthy задает минимум живых бэкендов, при котором уровень приоритета обслуживает трафик
+func (ab *AtomicBalancer) SetMinHealthy(n int) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	if n < 1 {
+		n = 1
+	}
+	ab.minHealthy = n
+	ab.refreshLocked()
+}
+
+// SetStrategy заменяет стратегию, сразу передавая ей текущих кандидатов
 func (ab *AtomicBalancer) SetStrategy(newBalancer Balancer) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	newBalancer.Update(ab.candidateList())
 	ab.Store(newBalancer)
 }
 
+// refreshLocked пересчитывает кандидатов и обновляет стратегию. Вызывается под ab.mu.
+func (ab *AtomicBalancer) refreshLocked() {
+	filters := *ab.filters.Load()
+	available := make([]backend.Backend, 0, len(ab.healthy))
+	for _, b := range ab.healthy {
+		if !excluded(filters, b.URL) {
+			available = append(available, b)
+		}
+	}
+	list := selectTier(available, ab.minHealthy)
+
+	set := make(map[string]time.Time, len(list))
+	for _, b := range list {
+		set[b.URL] = ab.healthySince[b.URL]
+	}
+	ab.candidates.Store(&set)
+
+	ab.Load().Update(list)
+}
+
+// candidateList возвращает кандидатов в порядке списка живых. Вызывается под ab.mu.
+func (ab *AtomicBalancer) candidateList() []backend.Backend {
+	set := *ab.candidates.Load()
+	list := make([]backend.Backend, 0, len(set))
+	for _, b := range ab.healthy {
+		if _, ok := set[b.URL]; ok {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// maxPicks сколько раз AtomicBalancer спрашивает стратегию без Picker,
+// прежде чем отказаться от выбора
+const maxPicks = 5
+
+// picker возвращает стратегию как Picker. Стратегию без Pick спрашивают заново,
+// пока она не выдаст разрешенный бэкенд, но не больше maxPicks раз.
+func picker(b Balancer) Picker {
+	if p, ok := b.(Picker); ok {
+		return p
+	}
+	return repicker{b}
+}
+
+type repicker struct {
+	Balancer
+}
+
+func (r repicker) Pick(key string, allow func(string) bool) (string, error) {
+	for pick := 1; pick <= maxPicks; pick++ {
+		pickKey := key
+		if pick > 1 {
+			pickKey = key + "#" + strconv.Itoa(pick)
+		}
+		backend, err := r.Next(pickKey)
+		if err != nil || allowed(allow, backend) {
+			return backend, err
+		}
+	}
+	return "", ErrNoHealthyBackends
+}
+
+// allowed сообщает, разрешен ли бэкенд в Picker.Pick
+func allowed(allow func(string) bool, backend string) bool {
+	return allow == nil || allow(backend)
+}
+
+func excluded(filters []Filter, backend string) bool {
+	for _, f := range filters {
+		if f.Excluded(backend) {
+			return true
+		}
+	}
+	return false
+}
+
+func admit(filters []Filter, backend string) bool {
+	for _, f := range filters {
+		if !f.Admit(backend) {
+			return false
+		}
+	}
+	return true
+}
+
 func (ab *AtomicBalancer) Store(b Balancer) {
 	if b == nil {
 		panic("nil balancer")
 	}
-	ab.value.Store(b)
+	ab.value.Store(holder{b})
 }
 
 func (ab *AtomicBalancer) Load() Balancer {
-	return ab.value.Load().(Balancer)
+	return ab.value.Load().(holder).Balancer
+}
+
+// holder нужен atomic.Value: в него нельзя сохранять значения разных конкретных типов,
+// а стратегии меняются на лету
+type holder struct {
+	Balancer
 }
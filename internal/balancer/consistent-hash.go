@@ -0,0 +1,97 @@
+package balancer
+
+import (
+	"hash/fnv"
+	"load-balancer/internal/backend"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// virtualNodesPerWeight число виртуальных узлов на единицу веса бэкенда.
+// Чем больше узлов, тем равномернее распределение ключей по кольцу.
+const virtualNodesPerWeight = 160
+
+// ConsistentHash закрепляет ключ клиента за бэкендом с помощью кольца
+// с виртуальными узлами. При добавлении или удалении бэкенда
+// перераспределяется только ~1/N ключей.
+type ConsistentHash struct {
+	ring []ringNode // Отсортировано по hash
+	mu   sync.RWMutex
+}
+
+type ringNode struct {
+	hash    uint64
+	backend string
+}
+
+func NewConsistentHash(backends []backend.Backend) Balancer {
+	c := &ConsistentHash{}
+	c.Update(backends)
+	return c
+}
+
+func (c *ConsistentHash) Next(key string) (string, error) {
+	return c.Pick(key, nil)
+}
+
+// Pick выбирает первый разрешенный узел по часовой стрелке от позиции ключа,
+// поэтому ключ запрещенного бэкенда стабильно уходит на один и тот же соседний
+func (c *ConsistentHash) Pick(key string, allow func(string) bool) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	n := len(c.ring)
+	h := hashKey(key)
+	start := sort.Search(n, func(i int) bool {
+		return c.ring[i].hash >= h
+	})
+	for i := 0; i < n; i++ {
+		if node := c.ring[(start+i)%n]; allowed(allow, node.backend) {
+			return node.backend, nil
+		}
+	}
+	return "", ErrNoHealthyBackends
+}
+
+func (c *ConsistentHash) Update(backends []backend.Backend) {
+	ring := make([]ringNode, 0, len(backends)*virtualNodesPerWeight)
+	for _, b := range backends {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = backend.DefaultWeight
+		}
+		// Позиции узлов зависят только от URL, поэтому не меняются
+		// при изменении состава остальных бэкендов
+		for i := 0; i < weight*virtualNodesPerWeight; i++ {
+			ring = append(ring, ringNode{
+				hash:    hashKey(b.URL + "#" + strconv.Itoa(i)),
+				backend: b.URL,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		if ring[i].hash == ring[j].hash {
+			return ring[i].backend < ring[j].backend
+		}
+		return ring[i].hash < ring[j].hash
+	})
+
+	c.mu.Lock()
+	c.ring = ring
+	c.mu.Unlock()
+}
+
+// hashKey FNV-1a с финальным перемешиванием (splitmix64),
+// чтобы похожие строки равномерно расходились по кольцу
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
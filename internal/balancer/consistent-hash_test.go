@@ -0,0 +1,56 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"strconv"
+	"testing"
+)
+
+func TestConsistentHashStickiness(t *testing.T) {
+	ch := NewConsistentHash(abc)
+
+	first, err := ch.Next("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if got, _ := ch.Next("10.0.0.1"); got != first {
+			t.Fatalf("key moved from %s to %s", first, got)
+		}
+	}
+}
+
+func TestConsistentHashMinimalDisruption(t *testing.T) {
+	backends := make([]backend.Backend, 0, 5)
+	for i := 0; i < 5; i++ {
+		backends = append(backends, backend.Backend{URL: "http://b" + strconv.Itoa(i)})
+	}
+	ch := NewConsistentHash(backends)
+
+	const keys = 10000
+	before := make([]string, keys)
+	for i := range before {
+		before[i], _ = ch.Next("client-" + strconv.Itoa(i))
+	}
+
+	// Убираем один бэкенд: переехать должны только его ключи
+	removed := backends[2].URL
+	ch.Update(append(append([]backend.Backend(nil), backends[:2]...), backends[3:]...))
+
+	moved := 0
+	for i := range before {
+		after, _ := ch.Next("client-" + strconv.Itoa(i))
+		if after == before[i] {
+			continue
+		}
+		if before[i] != removed {
+			t.Fatalf("key %d moved from healthy backend %s to %s", i, before[i], after)
+		}
+		moved++
+	}
+
+	// Ожидаем около keys/5 переехавших ключей
+	if moved < keys/10 || moved > keys*3/10 {
+		t.Errorf("unexpected number of moved keys: %d of %d", moved, keys)
+	}
+}
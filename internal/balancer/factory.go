@@ -2,33 +2,50 @@ package balancer
 
 import (
 	"errors"
+	"load-balancer/internal/backend"
 	"log/slog"
 )
 
-var ErrNoHealthyBackends = errors.New("no healthy backends available")
+var (
+	ErrNoHealthyBackends = errors.New("no healthy backends available")
+	ErrNoOtherBackends   = errors.New("no other healthy backends available")
+)
 
 type StrategyFactory interface {
-	Create(strategy string, backends []string) Balancer
+	Create(strategy string, backends []backend.Backend) Balancer
 }
 
-type defaultStrategyFactory struct{}
+type defaultStrategyFactory struct {
+	stats *Stats // Статистика бэкендов для стратегий, которые ее учитывают
+}
 
-func NewStrategyFactory() StrategyFactory {
-	return &defaultStrategyFactory{}
+func NewStrategyFactory(stats *Stats) StrategyFactory {
+	if stats == nil {
+		stats = NewStats()
+	}
+	return &defaultStrategyFactory{stats: stats}
 }
 
-func (f *defaultStrategyFactory) Create(strategy string, backends []string) Balancer {
+func (f *defaultStrategyFactory) Create(strategy string, backends []backend.Backend) Balancer {
 	switch strategy {
 	case "round-robin":
 		return NewRoundRobin(backends)
 	case "random":
 		return NewRandom(backends)
+	case "least-connections":
+		return NewLeastConnections(backends, f.stats)
+	case "weighted-round-robin":
+		return NewWeightedRoundRobin(backends)
+	case "consistent-hash":
+		return NewConsistentHash(backends)
+	case "p2c-ewma":
+		return NewP2CEWMA(backends, f.stats)
 	default:
 		slog.Warn("unknown strategy, using round-robin", slog.String("strategy", strategy))
 		return NewRoundRobin(backends)
 	}
 }
-func NewBalancer(strategy string, backends []string) Balancer {
+func NewBalancer(strategy string, backends []backend.Backend) Balancer {
 	switch strategy {
 	case "round-robin":
 		return NewRoundRobin(backends)
@@ -0,0 +1,36 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Result итог попытки запроса к бэкенду
+type Result struct {
+	Start  time.Time     // Начало попытки, после выбора бэкенда; нулевое - неизвестно
+	RTT    time.Duration // Время до получения заголовков ответа бэкенда (или до ошибки)
+	Status int           // Код ответа бэкенда; 0 - ответа не было
+	Err    error         // Ошибка проксирования
+}
+
+// Failed сообщает, что попытка считается отказом бэкенда:
+// ошибка соединения/таймаут или ответ 5xx. Отмена запроса клиентом отказом не считается.
+func (r Result) Failed() bool {
+	if r.Err != nil {
+		return !errors.Is(r.Err, context.Canceled)
+	}
+	return r.Status >= 500
+}
+
+// Filter дополнительно ограничивает выбор бэкендов поверх health checker'а
+// (например, circuit breaker). Реализации должны быть потокобезопасны.
+// При изменении результата Excluded фильтр должен вызвать AtomicBalancer.Refresh.
+type Filter interface {
+	// Excluded сообщает, что бэкенд должен быть исключен из кандидатов
+	Excluded(backend string) bool
+	// Admit вызывается при выборе бэкенда стратегией; false - выбрать другой
+	Admit(backend string) bool
+	// Observe получает итог каждой попытки запроса к бэкенду
+	Observe(backend string, res Result)
+}
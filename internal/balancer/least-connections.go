@@ -0,0 +1,60 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"sync"
+)
+
+// LeastConnections выбирает бэкенд с наименьшим числом активных запросов.
+// При равенстве счетчиков бэкенды перебираются по кругу, чтобы не нагружать первый.
+type LeastConnections struct {
+	backends []string
+	stats    *Stats
+	offset   int
+	mu       sync.Mutex
+}
+
+func NewLeastConnections(backends []backend.Backend, stats *Stats) Balancer {
+	return &LeastConnections{
+		backends: backend.URLs(backends),
+		stats:    stats,
+	}
+}
+
+func (l *LeastConnections) Next(key string) (string, error) {
+	return l.Pick(key, nil)
+}
+
+// Pick выбирает наименее загруженный бэкенд среди разрешенных
+func (l *LeastConnections) Pick(_ string, allow func(string) bool) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := len(l.backends)
+	best, bestLoad := "", int64(-1)
+	for i := 0; i < n && bestLoad != 0; i++ {
+		candidate := l.backends[(l.offset+i)%n]
+		if !allowed(allow, candidate) {
+			continue
+		}
+		if load := l.stats.InFlight(candidate); bestLoad < 0 || load < bestLoad {
+			best, bestLoad = candidate, load
+		}
+	}
+	if bestLoad < 0 {
+		return "", ErrNoHealthyBackends
+	}
+	l.offset = (l.offset + 1) % n
+
+	return best, nil
+}
+
+func (l *LeastConnections) Update(backends []backend.Backend) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.backends = backend.URLs(backends)
+	if l.offset >= len(l.backends) {
+		l.offset = 0
+	}
+}
@@ -0,0 +1,89 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"sync"
+	"testing"
+)
+
+var abc = []backend.Backend{{URL: "a"}, {URL: "b"}, {URL: "c"}}
+
+func TestLeastConnectionsPicksLeastLoaded(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewLeastConnections(abc, stats), stats)
+
+	// Занимаем a и b, c остается свободным
+	stats.Acquire("a")
+	stats.Acquire("a")
+	stats.Acquire("b")
+
+	for i := 0; i < 3; i++ {
+		got, err := ab.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && got != "c" {
+			t.Errorf("expected c, got %s", got)
+		}
+	}
+
+	// После трех выборов нагрузка должна выровняться: a=2, b=2, c=2
+	for _, b := range []string{"a", "b", "c"} {
+		if n := stats.InFlight(b); n != 2 {
+			t.Errorf("backend %s: expected 2 in-flight, got %d", b, n)
+		}
+	}
+
+	ab.Done("c", Result{})
+	if got, _ := ab.Next(""); got != "c" {
+		t.Errorf("expected c after Done, got %s", got)
+	}
+}
+
+func TestLeastConnectionsConcurrency(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewLeastConnections(abc, stats), stats)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b, err := ab.Next(""); err == nil {
+				ab.Done(b, Result{})
+			}
+			ab.Update(abc[:2])
+		}()
+	}
+	wg.Wait()
+
+	for _, b := range []string{"a", "b", "c"} {
+		if n := stats.InFlight(b); n != 0 {
+			t.Errorf("backend %s: expected 0 in-flight, got %d", b, n)
+		}
+	}
+}
+
+func TestLeastConnectionsNextExcluding(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewLeastConnections(abc, stats), stats)
+
+	// a наименее загружен, но уже отказал: повтор должен уйти на b или c
+	stats.Acquire("b")
+	stats.Acquire("c")
+	stats.Acquire("c")
+
+	first, err := ab.Next("")
+	if err != nil || first != "a" {
+		t.Fatalf("Next = %q, %v; want a", first, err)
+	}
+	ab.Done(first, Result{Status: 503})
+
+	second, err := ab.NextExcluding("", []string{"a"})
+	if err != nil || second != "b" {
+		t.Fatalf("NextExcluding = %q, %v; want b", second, err)
+	}
+	if _, err := ab.NextExcluding("", []string{"a", "b", "c"}); err != ErrNoOtherBackends {
+		t.Errorf("err = %v, want ErrNoOtherBackends", err)
+	}
+}
@@ -0,0 +1,217 @@
+package balancer
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout время ожидания завершения активных запросов при выводе бэкенда
+const DefaultDrainTimeout = 30 * time.Second
+
+// drainPollInterval как часто проверяется число активных запросов выводимого бэкенда
+const drainPollInterval = 100 * time.Millisecond
+
+// AdminState режим бэкенда, заданный оператором
+type AdminState int
+
+const (
+	AdminEnabled  AdminState = iota // Бэкенд в ротации, если жив
+	AdminDraining                   // Новые запросы не выдаются, активные завершаются
+	AdminDrained                    // Вывод завершен, бэкенд вне ротации
+	AdminDisabled                   // Бэкенд выключен оператором
+)
+
+func (s AdminState) String() string {
+	switch s {
+	case AdminEnabled:
+		return "enabled"
+	case AdminDraining:
+		return "draining"
+	case AdminDrained:
+		return "drained"
+	case AdminDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+// Maintenance выводит бэкенды из ротации по команде оператора. Реализует Filter.
+// Режим действует поверх результатов health checker'а и сохраняется между раундами проверок.
+type Maintenance struct {
+	stats   *Stats
+	refresh func()
+
+	mu     sync.Mutex
+	states map[string]*adminState
+}
+
+type adminState struct {
+	state AdminState
+	done  chan struct{} // Закрывается, когда вывод завершен или отменен
+}
+
+var _ Filter = (*Maintenance)(nil)
+
+// NewMaintenance создает управление режимами бэкендов. stats - общая статистика балансировщика,
+// refresh вызывается при смене режима (обычно AtomicBalancer.Refresh).
+func NewMaintenance(stats *Stats, refresh func()) *Maintenance {
+	return &Maintenance{
+		stats:   stats,
+		refresh: refresh,
+		states:  make(map[string]*adminState),
+	}
+}
+
+// Drain прекращает выдачу бэкенда и ждет завершения его активных запросов, но не дольше timeout.
+// Возвращаемый канал закрывается по завершении вывода (или при Enable/Disable).
+func (m *Maintenance) Drain(backend string, timeout time.Duration) <-chan struct{} {
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+
+	m.mu.Lock()
+	if s, ok := m.states[backend]; ok && s.state == AdminDraining {
+		m.mu.Unlock()
+		return s.done // Вывод уже идет
+	}
+	s := m.set(backend, AdminDraining)
+	m.mu.Unlock()
+
+	slog.Info("Maintenance: draining backend",
+		slog.String("backend", backend),
+		slog.Int64("in_flight", m.stats.InFlight(backend)),
+		slog.Duration("timeout", timeout))
+	m.refresh()
+
+	go m.wait(backend, s, time.Now().Add(timeout))
+	return s.done
+}
+
+// Disable сразу выводит бэкенд из ротации; активные запросы не прерываются
+func (m *Maintenance) Disable(backend string) {
+	m.mu.Lock()
+	m.set(backend, AdminDisabled)
+	m.mu.Unlock()
+
+	slog.Info("Maintenance: backend disabled", slog.String("backend", backend))
+	m.refresh()
+}
+
+// Enable возвращает бэкенд в ротацию
+func (m *Maintenance) Enable(backend string) {
+	m.mu.Lock()
+	_, ok := m.states[backend]
+	if ok {
+		m.set(backend, AdminEnabled)
+		delete(m.states, backend)
+	}
+	m.mu.Unlock()
+
+	if ok {
+		slog.Info("Maintenance: backend enabled", slog.String("backend", backend))
+		m.refresh()
+	}
+}
+
+// RetainBackends забывает режимы бэкендов, которых нет в backends (убраны из конфигурации),
+// чтобы снова добавленный бэкенд вернулся в ротацию. Незавершенный вывод отменяется.
+func (m *Maintenance) RetainBackends(backends []string) {
+	keep := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		keep[b] = true
+	}
+
+	m.mu.Lock()
+	var removed []string
+	for b, s := range m.states {
+		if keep[b] {
+			continue
+		}
+		if s.state == AdminDraining {
+			close(s.done)
+		}
+		delete(m.states, b)
+		removed = append(removed, b)
+	}
+	m.mu.Unlock()
+
+	for _, b := range removed {
+		slog.Info("Maintenance: backend removed from config, state cleared", slog.String("backend", b))
+	}
+	if len(removed) > 0 {
+		m.refresh()
+	}
+}
+
+// State возвращает режим бэкенда
+func (m *Maintenance) State(backend string) AdminState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.states[backend]; ok {
+		return s.state
+	}
+	return AdminEnabled
+}
+
+func (m *Maintenance) Excluded(backend string) bool {
+	return m.State(backend) != AdminEnabled
+}
+
+func (m *Maintenance) Admit(string) bool {
+	return true
+}
+
+func (m *Maintenance) Observe(string, Result) {}
+
+// set меняет режим бэкенда, завершая незаконченный вывод. Вызывается под m.mu.
+func (m *Maintenance) set(backend string, state AdminState) *adminState {
+	if old, ok := m.states[backend]; ok && old.state == AdminDraining {
+		close(old.done)
+	}
+	s := &adminState{state: state, done: make(chan struct{})}
+	if state != AdminDraining {
+		close(s.done)
+	}
+	m.states[backend] = s
+	return s
+}
+
+// wait завершает вывод, когда активных запросов не осталось или истек срок
+func (m *Maintenance) wait(backend string, s *adminState, deadline time.Time) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return // Вывод отменен
+		case <-ticker.C:
+		}
+
+		inFlight := m.stats.InFlight(backend)
+		timedOut := time.Now().After(deadline)
+		if inFlight > 0 && !timedOut {
+			continue
+		}
+
+		m.mu.Lock()
+		if m.states[backend] != s {
+			m.mu.Unlock()
+			return
+		}
+		s.state = AdminDrained
+		close(s.done)
+		m.mu.Unlock()
+
+		if timedOut && inFlight > 0 {
+			slog.Warn("Maintenance: drain timed out",
+				slog.String("backend", backend),
+				slog.Int64("in_flight", inFlight))
+		} else {
+			slog.Info("Maintenance: backend drained", slog.String("backend", backend))
+		}
+		return
+	}
+}
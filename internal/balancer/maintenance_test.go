@@ -0,0 +1,118 @@
+package balancer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceDrain(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewRoundRobin(nil), stats)
+	m := NewMaintenance(stats, ab.Refresh)
+	ab.AddFilter(m)
+	ab.Update(abc)
+
+	// Активный запрос к a
+	if !ab.Pin("a") {
+		t.Fatal("pin failed")
+	}
+
+	done := m.Drain("a", time.Minute)
+	// Health checker снова сообщает, что a жив: вывод должен сохраниться
+	ab.Update(abc)
+	for i := 0; i < 10; i++ {
+		b, err := ab.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b == "a" {
+			t.Fatal("draining backend selected")
+		}
+		ab.Done(b, Result{})
+	}
+	if m.State("a") != AdminDraining {
+		t.Fatalf("state = %s, want draining", m.State("a"))
+	}
+
+	ab.Done("a", Result{})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not complete after in-flight reached zero")
+	}
+	if m.State("a") != AdminDrained {
+		t.Fatalf("state = %s, want drained", m.State("a"))
+	}
+
+	m.Enable("a")
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		b, _ := ab.Next("")
+		seen[b] = true
+		ab.Done(b, Result{})
+	}
+	if !seen["a"] {
+		t.Fatal("enabled backend not selected")
+	}
+}
+
+func TestMaintenanceDisableAndTimeout(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewRoundRobin(nil), stats)
+	m := NewMaintenance(stats, ab.Refresh)
+	ab.AddFilter(m)
+	ab.Update(abc[:2])
+
+	m.Disable("a")
+	if ab.Pin("a") {
+		t.Fatal("disabled backend pinned")
+	}
+
+	// Зависший запрос к b: вывод завершается по таймауту
+	ab.Pin("b")
+	select {
+	case <-m.Drain("b", 150*time.Millisecond):
+	case <-time.After(time.Second):
+		t.Fatal("drain did not time out")
+	}
+	if _, err := ab.Next(""); err != ErrNoHealthyBackends {
+		t.Fatalf("err = %v, want ErrNoHealthyBackends", err)
+	}
+}
+
+func TestMaintenanceRetainBackends(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewRoundRobin(nil), stats)
+	m := NewMaintenance(stats, ab.Refresh)
+	ab.AddFilter(m)
+	ab.Update(abc)
+
+	m.Disable("a")
+	ab.Pin("b")
+	done := m.Drain("b", time.Minute)
+
+	// a и b удалены из конфигурации: их режимы забываются, вывод b отменяется
+	m.RetainBackends([]string{"c"})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain was not cancelled")
+	}
+	for _, b := range []string{"a", "b"} {
+		if s := m.State(b); s != AdminEnabled {
+			t.Errorf("state of %s = %s, want enabled", b, s)
+		}
+	}
+
+	// Снова добавленный бэкенд возвращается в ротацию
+	ab.Done("b", Result{})
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		b, _ := ab.Next("")
+		seen[b] = true
+		ab.Done(b, Result{})
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Errorf("re-added backends not selected: %v", seen)
+	}
+}
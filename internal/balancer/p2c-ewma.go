@@ -0,0 +1,79 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"math/rand/v2"
+	"sync"
+)
+
+// P2CEWMA реализует "power of two choices": случайно выбираются два бэкенда
+// и из них берется тот, у которого меньше стоимость (peak EWMA задержки * активные запросы).
+// Подход Finagle/Linkerd: медленный бэкенд быстро теряет трафик, но не исключается полностью.
+type P2CEWMA struct {
+	backends []string
+	stats    *Stats
+	mu       sync.RWMutex
+}
+
+func NewP2CEWMA(backends []backend.Backend, stats *Stats) Balancer {
+	return &P2CEWMA{
+		backends: backend.URLs(backends),
+		stats:    stats,
+	}
+}
+
+func (p *P2CEWMA) Next(key string) (string, error) {
+	return p.Pick(key, nil)
+}
+
+// Pick сравнивает два случайных бэкенда из разрешенных
+func (p *P2CEWMA) Pick(_ string, allow func(string) bool) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	n := 0
+	for _, b := range p.backends {
+		if allowed(allow, b) {
+			n++
+		}
+	}
+	switch n {
+	case 0:
+		return "", ErrNoHealthyBackends
+	case 1:
+		return p.nth(allow, 0), nil
+	}
+
+	// Два различных случайных индекса среди разрешенных
+	i := rand.IntN(n)
+	j := rand.IntN(n - 1)
+	if j >= i {
+		j++
+	}
+
+	a, b := p.nth(allow, i), p.nth(allow, j)
+	if p.stats.Cost(b) < p.stats.Cost(a) {
+		return b, nil
+	}
+	return a, nil
+}
+
+// nth возвращает i-й разрешенный бэкенд. Вызывается под p.mu.
+func (p *P2CEWMA) nth(allow func(string) bool, i int) string {
+	for _, b := range p.backends {
+		if !allowed(allow, b) {
+			continue
+		}
+		if i == 0 {
+			return b
+		}
+		i--
+	}
+	return ""
+}
+
+func (p *P2CEWMA) Update(backends []backend.Backend) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backends = backend.URLs(backends)
+}
@@ -0,0 +1,53 @@
+package balancer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestP2CEWMAAvoidsSlowBackend(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewP2CEWMA(abc[:2], stats), stats)
+
+	// a отвечает в 10 раз медленнее b
+	stats.Observe("a", time.Second)
+	stats.Observe("b", 100*time.Millisecond)
+
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		b, err := ab.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[b]++
+		ab.Done(b, Result{})
+	}
+
+	// Из двух бэкендов p2c всегда видит оба, поэтому медленный не выбирается
+	if counts["a"] != 0 {
+		t.Errorf("slow backend was picked %d times", counts["a"])
+	}
+}
+
+func TestStatsPeakEWMA(t *testing.T) {
+	stats := NewStats()
+
+	stats.Observe("a", 10*time.Millisecond)
+	stats.Observe("a", time.Second)
+	// Всплеск учитывается сразу
+	if cost := stats.Cost("a"); cost < float64(900*time.Millisecond) {
+		t.Errorf("peak not applied, cost=%v", time.Duration(cost))
+	}
+
+	// Быстрый ответ сразу после всплеска почти не снижает оценку
+	stats.Observe("a", 10*time.Millisecond)
+	if cost := stats.Cost("a"); cost < float64(900*time.Millisecond) {
+		t.Errorf("ewma decayed too fast, cost=%v", time.Duration(cost))
+	}
+
+	// Бэкенд без замеров, но с активными запросами, считается дорогим
+	stats.Acquire("b")
+	if stats.Cost("b") <= stats.Cost("a") {
+		t.Error("expected penalty for backend without samples")
+	}
+}
@@ -1,6 +1,7 @@
 package balancer
 
 import (
+	"load-balancer/internal/backend"
 	"math/rand/v2"
 	"sync"
 )
@@ -10,25 +11,40 @@ type Random struct {
 	mu       sync.RWMutex
 }
 
-func NewRandom(backends []string) Balancer {
+func NewRandom(backends []backend.Backend) Balancer {
 	return &Random{
-		backends: append([]string(nil), backends...),
+		backends: backend.URLs(backends),
 	}
 }
 
-func (r *Random) Next() (string, error) {
+func (r *Random) Next(key string) (string, error) {
+	return r.Pick(key, nil)
+}
+
+// Pick выбирает случайный бэкенд среди разрешенных
+func (r *Random) Pick(_ string, allow func(string) bool) (string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if len(r.backends) == 0 {
+	// Выборка с резервуаром из одного элемента: равновероятно и без аллокаций
+	chosen, seen := "", 0
+	for _, b := range r.backends {
+		if !allowed(allow, b) {
+			continue
+		}
+		seen++
+		if rand.IntN(seen) == 0 {
+			chosen = b
+		}
+	}
+	if seen == 0 {
 		return "", ErrNoHealthyBackends
 	}
-
-	return r.backends[rand.IntN(len(r.backends))], nil
+	return chosen, nil
 }
 
-func (r *Random) Update(backends []string) {
+func (r *Random) Update(backends []backend.Backend) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.backends = append([]string(nil), backends...)
+	r.backends = backend.URLs(backends)
 }
@@ -1,6 +1,7 @@
 package balancer
 
 import (
+	"load-balancer/internal/backend"
 	"sync"
 )
 
@@ -10,37 +11,44 @@ type RoundRobin struct {
 	mu       sync.Mutex
 }
 
-func NewRoundRobin(backends []string) Balancer {
-	return &RoundRobin{backends: backends}
+func NewRoundRobin(backends []backend.Backend) Balancer {
+	return &RoundRobin{backends: backend.URLs(backends)}
 }
 
-func (r *RoundRobin) Update(backends []string) {
+func (r *RoundRobin) Update(backends []backend.Backend) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	urls := backend.URLs(backends)
 	// Сбрасываем индекс при изменении списка
-	if !slicesEqual(r.backends, backends) {
+	if !slicesEqual(r.backends, urls) {
 		r.index = 0
 	}
 
-	r.backends = append([]string(nil), backends...)
+	r.backends = urls
 	if len(r.backends) > 0 && r.index >= len(r.backends) {
 		r.index = 0
 	}
 }
 
-func (r *RoundRobin) Next() (string, error) {
+func (r *RoundRobin) Next(key string) (string, error) {
+	return r.Pick(key, nil)
+}
+
+// Pick выдает следующий по кругу разрешенный бэкенд
+func (r *RoundRobin) Pick(_ string, allow func(string) bool) (string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if len(r.backends) == 0 {
-		return "", ErrNoHealthyBackends
+	n := len(r.backends)
+	for i := 0; i < n; i++ {
+		backend := r.backends[(r.index+i)%n]
+		if allowed(allow, backend) {
+			r.index = (r.index + i + 1) % n
+			return backend, nil
+		}
 	}
-
-	backend := r.backends[r.index]
-	r.index = (r.index + 1) % len(r.backends)
-
-	return backend, nil
+	return "", ErrNoHealthyBackends
 }
 
 // slicesEqual Вспомогательная функция для сравнения слайсов
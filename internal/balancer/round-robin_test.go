@@ -1,20 +1,21 @@
 package balancer
 
 import (
+	"load-balancer/internal/backend"
 	"sync"
 	"testing"
 )
 
 func TestRoundRobinConcurrency(t *testing.T) {
-	rr := NewRoundRobin([]string{"a", "b", "c"})
+	rr := NewRoundRobin([]backend.Backend{{URL: "a"}, {URL: "b"}, {URL: "c"}})
 
 	var wg sync.WaitGroup
 	for i := 0; i < 100; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			rr.Next()
-			rr.Update([]string{"a", "b"})
+			rr.Next("")
+			rr.Update([]backend.Backend{{URL: "a"}, {URL: "b"}})
 		}()
 	}
 	wg.Wait()
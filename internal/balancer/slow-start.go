@@ -0,0 +1,57 @@
+package balancer
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// SlowStart описывает плавный ввод восстановившегося бэкенда в работу.
+// Эффективный вес растет от MinFactor до 1 за Window по кривой (t/Window)^(1/Aggression):
+// Aggression = 1 - линейно, > 1 - быстрее в начале, < 1 - медленнее в начале.
+// Работает с любой стратегией: перед каждым выбором прогревающийся бэкенд допускается
+// к нему с вероятностью, равной его текущему коэффициенту, и стратегия выбирает
+// среди допущенных. Так least-connections не отдает весь трафик свободному
+// восстановившемуся бэкенду.
+type SlowStart struct {
+	Window     time.Duration // 0 - slow start выключен
+	MinFactor  float64       // Начальная доля веса, 0..1
+	Aggression float64
+}
+
+// factor возвращает текущую долю веса бэкенда, который стал живым elapsed назад
+func (s SlowStart) factor(elapsed time.Duration) float64 {
+	if s.Window <= 0 || elapsed >= s.Window {
+		return 1
+	}
+
+	aggression := s.Aggression
+	if aggression <= 0 {
+		aggression = 1
+	}
+	f := math.Pow(float64(elapsed)/float64(s.Window), 1/aggression)
+	return math.Min(1, math.Max(f, s.MinFactor))
+}
+
+// cold возвращает прогревающихся кандидатов, не допущенных к очередному выбору
+func (s SlowStart) cold(candidates map[string]time.Time) []string {
+	if s.Window <= 0 {
+		return nil
+	}
+	var list []string
+	for b, since := range candidates {
+		if !s.admit(since) {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// admit решает, допустить ли к выбору бэкенд, живой с момента since
+func (s SlowStart) admit(since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+	f := s.factor(time.Since(since))
+	return f >= 1 || rand.Float64() < f
+}
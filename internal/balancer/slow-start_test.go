@@ -0,0 +1,91 @@
+package balancer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowStartFactor(t *testing.T) {
+	s := SlowStart{Window: 10 * time.Second, MinFactor: 0.1, Aggression: 1}
+
+	cases := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 0.1},
+		{5 * time.Second, 0.5},
+		{10 * time.Second, 1},
+		{time.Minute, 1},
+	}
+	for _, c := range cases {
+		if got := s.factor(c.elapsed); got != c.want {
+			t.Errorf("factor(%v) = %v, want %v", c.elapsed, got, c.want)
+		}
+	}
+
+	if got := (SlowStart{}).factor(0); got != 1 {
+		t.Errorf("disabled slow start: factor = %v, want 1", got)
+	}
+}
+
+func TestSlowStartRecoveredBackend(t *testing.T) {
+	ab := NewAtomicBalancer(NewRoundRobin(nil), nil)
+	ab.SetSlowStart(SlowStart{Window: time.Hour, MinFactor: 0.1, Aggression: 1})
+
+	// Первый список принимается без прогрева, затем c падает и восстанавливается
+	ab.Update(abc)
+	ab.Update(abc[:2])
+	ab.Update(abc)
+
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		b, err := ab.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[b]++
+		ab.Done(b, Result{})
+	}
+
+	// Без slow start c получил бы треть запросов
+	if counts["c"] > 300 {
+		t.Errorf("recovered backend got too much traffic: %v", counts)
+	}
+	if counts["c"] == 0 {
+		t.Errorf("recovered backend got no traffic: %v", counts)
+	}
+}
+
+func TestSlowStartLeastConnections(t *testing.T) {
+	stats := NewStats()
+	ab := NewAtomicBalancer(NewLeastConnections(nil, stats), stats)
+	ab.SetSlowStart(SlowStart{Window: time.Hour, MinFactor: 0.1, Aggression: 1})
+
+	ab.Update(abc)
+	ab.Update(abc[:2])
+	ab.Update(abc)
+
+	// a и b заняты долгими запросами, восстановившийся c свободен:
+	// без slow start least-connections отдал бы c все запросы
+	for i := 0; i < 3; i++ {
+		stats.Acquire("a")
+		stats.Acquire("b")
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		b, err := ab.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[b]++
+		ab.Done(b, Result{})
+	}
+
+	if counts["c"] > 600 {
+		t.Errorf("recovered backend got too much traffic: %v", counts)
+	}
+	if counts["c"] == 0 {
+		t.Errorf("recovered backend got no traffic: %v", counts)
+	}
+}
@@ -0,0 +1,130 @@
+package balancer
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// ewmaDecay период затухания EWMA задержки: старые замеры теряют вес за это время
+	ewmaDecay = 10 * time.Second
+	// ewmaPenalty стоимость бэкенда, по которому еще нет замеров, но уже есть активные запросы
+	ewmaPenalty = float64(time.Minute)
+)
+
+// Stats хранит счетчики активных (in-flight) запросов и оценку задержки по каждому бэкенду.
+// Общий для всех стратегий, поэтому данные не теряются при смене стратегии.
+type Stats struct {
+	mu       sync.RWMutex
+	backends map[string]*backendStats
+}
+
+type backendStats struct {
+	inFlight atomic.Int64
+
+	mu    sync.Mutex
+	ewma  float64   // Peak EWMA задержки в наносекундах
+	stamp time.Time // Время последнего обновления ewma
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		backends: make(map[string]*backendStats),
+	}
+}
+
+// Acquire отмечает начало запроса к бэкенду
+func (s *Stats) Acquire(backend string) {
+	s.get(backend).inFlight.Add(1)
+}
+
+// Release отмечает завершение запроса к бэкенду
+func (s *Stats) Release(backend string) {
+	c := &s.get(backend).inFlight
+	for {
+		cur := c.Load()
+		if cur <= 0 {
+			// Защита от ухода в минус (например, лишний Done)
+			return
+		}
+		if c.CompareAndSwap(cur, cur-1) {
+			return
+		}
+	}
+}
+
+// InFlight возвращает текущее число активных запросов к бэкенду
+func (s *Stats) InFlight(backend string) int64 {
+	b, ok := s.lookup(backend)
+	if !ok {
+		return 0
+	}
+	return b.inFlight.Load()
+}
+
+// Observe учитывает задержку очередного запроса к бэкенду.
+// Используется peak EWMA: всплеск задержки учитывается сразу,
+// а снижение - плавно, с затуханием ewmaDecay.
+func (s *Stats) Observe(backend string, rtt time.Duration) {
+	b := s.get(backend)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	sample := float64(rtt)
+	switch {
+	case b.stamp.IsZero() || sample > b.ewma:
+		b.ewma = sample
+	default:
+		w := math.Exp(-float64(now.Sub(b.stamp)) / float64(ewmaDecay))
+		b.ewma = b.ewma*w + sample*(1-w)
+	}
+	b.stamp = now
+}
+
+// Cost возвращает оценку стоимости запроса к бэкенду: EWMA задержки * (активные запросы + 1).
+// Без новых замеров EWMA затухает к нулю, чтобы бэкенд, который когда-то был медленным,
+// со временем снова получал трафик.
+func (s *Stats) Cost(backend string) float64 {
+	b, ok := s.lookup(backend)
+	if !ok {
+		return 0
+	}
+	inFlight := float64(b.inFlight.Load())
+
+	b.mu.Lock()
+	ewma := b.ewma
+	if !b.stamp.IsZero() {
+		ewma *= math.Exp(-float64(time.Since(b.stamp)) / float64(ewmaDecay))
+	}
+	b.mu.Unlock()
+
+	if ewma == 0 && inFlight > 0 {
+		return ewmaPenalty + inFlight
+	}
+	return ewma * (inFlight + 1)
+}
+
+func (s *Stats) lookup(backend string) (*backendStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.backends[backend]
+	return b, ok
+}
+
+func (s *Stats) get(backend string) *backendStats {
+	if b, ok := s.lookup(backend); ok {
+		return b
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.backends[backend]; ok {
+		return b
+	}
+	b := &backendStats{}
+	s.backends[backend] = b
+	return b
+}
@@ -0,0 +1,61 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// Strategies имена стратегий, которые создает StrategyFactory
+var Strategies = []string{
+	"round-robin",
+	"random",
+	"least-connections",
+	"weighted-round-robin",
+	"consistent-hash",
+	"p2c-ewma",
+}
+
+var ErrUnknownStrategy = errors.New("unknown strategy")
+
+// StrategySwitcher заменяет стратегию AtomicBalancer по имени и помнит текущую
+type StrategySwitcher struct {
+	ab      *AtomicBalancer
+	factory StrategyFactory
+
+	mu   sync.Mutex
+	name string
+}
+
+// NewStrategySwitcher создает переключатель; name - имя стратегии, уже установленной в ab
+func NewStrategySwitcher(ab *AtomicBalancer, factory StrategyFactory, name string) *StrategySwitcher {
+	return &StrategySwitcher{ab: ab, factory: factory, name: name}
+}
+
+// Name возвращает имя текущей стратегии
+func (s *StrategySwitcher) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
+
+// Switch атомарно заменяет стратегию. Если стратегия уже установлена, ничего не делает.
+func (s *StrategySwitcher) Switch(name string) error {
+	if !slices.Contains(Strategies, name) {
+		return fmt.Errorf("%w %q", ErrUnknownStrategy, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name == s.name {
+		return nil
+	}
+
+	// Список бэкендов новой стратегии задаст SetStrategy из текущих кандидатов
+	s.ab.SetStrategy(s.factory.Create(name, nil))
+	slog.Info("Balancer strategy switched", slog.String("from", s.name), slog.String("to", name))
+	s.name = name
+	return nil
+}
@@ -0,0 +1,32 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"sort"
+)
+
+// selectTier выбирает живые бэкенды уровня с наивысшим приоритетом (наименьшим Priority),
+// в котором не меньше minHealthy живых. Резервный уровень получает трафик только тогда,
+// когда основной не набирает минимум.
+// Если минимум не набирает ни один уровень, используются все живые бэкенды:
+// частичная работа лучше, чем 503 для всех клиентов.
+func selectTier(healthy []backend.Backend, minHealthy int) []backend.Backend {
+	tiers := make(map[int][]backend.Backend)
+	for _, b := range healthy {
+		tiers[b.Priority] = append(tiers[b.Priority], b)
+	}
+
+	priorities := make([]int, 0, len(tiers))
+	for p := range tiers {
+		priorities = append(priorities, p)
+	}
+	sort.Ints(priorities)
+
+	for _, p := range priorities {
+		if len(tiers[p]) >= minHealthy {
+			return tiers[p]
+		}
+	}
+
+	return healthy
+}
@@ -0,0 +1,50 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"testing"
+)
+
+func TestTierFailover(t *testing.T) {
+	primary := []backend.Backend{
+		{URL: "p1", Priority: backend.PriorityPrimary},
+		{URL: "p2", Priority: backend.PriorityPrimary},
+	}
+	backup := []backend.Backend{
+		{URL: "b1", Priority: backend.PriorityBackup},
+		{URL: "b2", Priority: backend.PriorityBackup},
+	}
+
+	ab := NewAtomicBalancer(NewRoundRobin(nil), nil)
+	ab.SetMinHealthy(2)
+
+	// Основной уровень набирает минимум: резерв не получает трафик
+	ab.Update(append(append([]backend.Backend(nil), primary...), backup...))
+	for i := 0; i < 4; i++ {
+		if b, _ := ab.Next(""); b != "p1" && b != "p2" {
+			t.Fatal("backup got traffic while primary tier is healthy")
+		}
+	}
+	if ab.Pin("b1") {
+		t.Error("backup pinned while primary tier is healthy")
+	}
+
+	// В основном уровне остался один живой: трафик уходит в резерв
+	ab.Update(append([]backend.Backend{primary[0]}, backup...))
+	for i := 0; i < 4; i++ {
+		if b, _ := ab.Next(""); b == "p1" {
+			t.Fatal("expected failover to backup tier, got p1")
+		}
+	}
+
+	// Ни один уровень не набирает минимум: используются все живые
+	ab.SetMinHealthy(3)
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		b, _ := ab.Next("")
+		seen[b] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected all healthy backends, got %v", seen)
+	}
+}
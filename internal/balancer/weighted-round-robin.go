@@ -0,0 +1,82 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"sync"
+)
+
+// WeightedRoundRobin реализует плавный взвешенный round-robin (как в nginx):
+// бэкенды выбираются пропорционально весам, но без серий подряд на самый тяжелый узел.
+// Например, для весов {a:5, b:1, c:1} последовательность будет a a b a c a a.
+type WeightedRoundRobin struct {
+	peers []*weightedPeer
+	mu    sync.Mutex
+}
+
+type weightedPeer struct {
+	url     string
+	weight  int
+	current int // Текущий вес, накапливается на каждом выборе
+}
+
+func NewWeightedRoundRobin(backends []backend.Backend) Balancer {
+	w := &WeightedRoundRobin{}
+	w.Update(backends)
+	return w
+}
+
+func (w *WeightedRoundRobin) Next(key string) (string, error) {
+	return w.Pick(key, nil)
+}
+
+// Pick выбирает бэкенд среди разрешенных; запрещенные в этом выборе не участвуют,
+// как недоступные узлы в nginx
+func (w *WeightedRoundRobin) Pick(_ string, allow func(string) bool) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	total := 0
+	var best *weightedPeer
+	for _, p := range w.peers {
+		if !allowed(allow, p.url) {
+			continue
+		}
+		p.current += p.weight
+		total += p.weight
+		if best == nil || p.current > best.current {
+			best = p
+		}
+	}
+	if best == nil {
+		return "", ErrNoHealthyBackends
+	}
+	best.current -= total
+
+	return best.url, nil
+}
+
+func (w *WeightedRoundRobin) Update(backends []backend.Backend) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Сохраняем накопленный текущий вес оставшихся бэкендов,
+	// чтобы обновление списка не сбивало распределение
+	prev := make(map[string]int, len(w.peers))
+	for _, p := range w.peers {
+		prev[p.url] = p.current
+	}
+
+	peers := make([]*weightedPeer, 0, len(backends))
+	for _, b := range backends {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = backend.DefaultWeight
+		}
+		peers = append(peers, &weightedPeer{
+			url:     b.URL,
+			weight:  weight,
+			current: prev[b.URL],
+		})
+	}
+	w.peers = peers
+}
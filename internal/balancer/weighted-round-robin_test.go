@@ -0,0 +1,42 @@
+package balancer
+
+import (
+	"load-balancer/internal/backend"
+	"strings"
+	"testing"
+)
+
+func TestWeightedRoundRobinSmooth(t *testing.T) {
+	wrr := NewWeightedRoundRobin([]backend.Backend{
+		{URL: "a", Weight: 5},
+		{URL: "b", Weight: 1},
+		{URL: "c", Weight: 1},
+	})
+
+	picks := make([]string, 0, 7)
+	for i := 0; i < 7; i++ {
+		b, err := wrr.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		picks = append(picks, b)
+	}
+
+	if got, want := strings.Join(picks, " "), "a a b a c a a"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWeightedRoundRobinDefaultWeight(t *testing.T) {
+	wrr := NewWeightedRoundRobin([]backend.Backend{{URL: "a"}, {URL: "b", Weight: 0}})
+
+	counts := map[string]int{}
+	for i := 0; i < 10; i++ {
+		b, _ := wrr.Next("")
+		counts[b]++
+	}
+
+	if counts["a"] != 5 || counts["b"] != 5 {
+		t.Errorf("expected even split, got %v", counts)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Пакет breaker реализует circuit breaker для каждого бэкенда:
+- Закрытое состояние: учет отказов подряд и доли ошибок в скользящем окне
+- Открытое состояние: бэкенд исключается из кандидатов на OpenTimeout
+- Полуоткрытое состояние: ограниченное число пробных запросов решает, закрыть или снова открыть
+*/
+
+package breaker
+
+import (
+	"load-balancer/internal/balancer"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// State состояние circuit breaker бэкенда
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// buckets число корзин скользящего окна
+const buckets = 10
+
+type Config struct {
+	ConsecutiveFailures int           // Отказов подряд для размыкания; 0 - не учитывать
+	ErrorRatePercent    int           // Доля отказов в окне для размыкания; 0 - не учитывать
+	MinRequests         int           // Минимум запросов в окне, чтобы учитывать долю отказов
+	Window              time.Duration // Длина скользящего окна
+	OpenTimeout         time.Duration // Время в открытом состоянии до пробных запросов
+	HalfOpenProbes      int           // Одновременных пробных запросов в полуоткрытом состоянии
+}
+
+// Set хранит circuit breaker'ы всех бэкендов. Реализует balancer.Filter.
+type Set struct {
+	mu       sync.Mutex
+	cfg      Config
+	breakers map[string]*breaker
+	onChange func() // Вызывается вне блокировки при открытии и переходе в полуоткрытое состояние
+}
+
+type breaker struct {
+	state       State
+	consecutive int
+	window      [buckets]bucket
+	probes      int       // Активные пробные запросы
+	since       time.Time // Начало полуоткрытого состояния
+}
+
+type bucket struct {
+	start  time.Time
+	total  int
+	failed int
+}
+
+var _ balancer.Filter = (*Set)(nil)
+
+// New создает набор circuit breaker'ов. onChange вызывается, когда меняется
+// список исключенных бэкендов (обычно AtomicBalancer.Refresh).
+func New(cfg Config, onChange func()) *Set {
+	if onChange == nil {
+		onChange = func() {}
+	}
+	return &Set{
+		cfg:      normalize(cfg),
+		breakers: make(map[string]*breaker),
+		onChange: onChange,
+	}
+}
+
+// UpdateConfig применяет новые параметры; текущие состояния сохраняются
+func (s *Set) UpdateConfig(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = normalize(cfg)
+}
+
+// State возвращает состояние circuit breaker бэкенда
+func (s *Set) State(backend string) State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.breakers[backend]; ok {
+		return b.state
+	}
+	return Closed
+}
+
+// Excluded исключает бэкенды с разомкнутым circuit breaker
+func (s *Set) Excluded(backend string) bool {
+	return s.State(backend) == Open
+}
+
+// Admit в полуоткрытом состоянии пропускает не больше HalfOpenProbes запросов одновременно
+func (s *Set) Admit(backend string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[backend]
+	if !ok || b.state != HalfOpen {
+		return true
+	}
+	if b.probes >= s.cfg.HalfOpenProbes {
+		return false
+	}
+	b.probes++
+	return true
+}
+
+// Observe учитывает итог запроса к бэкенду
+func (s *Set) Observe(backend string, res balancer.Result) {
+	s.mu.Lock()
+	b := s.get(backend)
+	failed := res.Failed()
+
+	changed := false
+	switch b.state {
+	case Closed:
+		b.record(time.Now(), s.cfg.Window, failed)
+		if s.shouldTrip(b) {
+			s.open(backend, b)
+			changed = true
+		}
+	case HalfOpen:
+		// Запрос, начатый до полуоткрытого состояния, не пробный: он не занимал слот
+		// и не должен решать, закрыть ли breaker
+		if !res.Start.IsZero() && res.Start.Before(b.since) {
+			break
+		}
+		if b.probes > 0 {
+			b.probes--
+		}
+		if failed {
+			s.open(backend, b)
+			changed = true
+		} else {
+			*b = breaker{}
+			slog.Info("Circuit breaker closed", slog.String("backend", backend))
+		}
+	}
+	s.mu.Unlock()
+
+	if changed {
+		s.onChange()
+	}
+}
+
+func (s *Set) get(backend string) *breaker {
+	b, ok := s.breakers[backend]
+	if !ok {
+		b = &breaker{}
+		s.breakers[backend] = b
+	}
+	return b
+}
+
+func (s *Set) shouldTrip(b *breaker) bool {
+	if s.cfg.ConsecutiveFailures > 0 && b.consecutive >= s.cfg.ConsecutiveFailures {
+		return true
+	}
+	if s.cfg.ErrorRatePercent <= 0 {
+		return false
+	}
+	total, failed := b.counts(time.Now(), s.cfg.Window)
+	return total >= s.cfg.MinRequests && failed*100 >= total*s.cfg.ErrorRatePercent
+}
+
+// open размыкает circuit breaker и планирует переход в полуоткрытое состояние. Вызывается под s.mu.
+func (s *Set) open(backend string, b *breaker) {
+	*b = breaker{state: Open}
+	slog.Warn("Circuit breaker opened",
+		slog.String("backend", backend),
+		slog.Duration("open_timeout", s.cfg.OpenTimeout))
+
+	time.AfterFunc(s.cfg.OpenTimeout, func() {
+		s.mu.Lock()
+		// Состояние могло смениться, если breaker пересоздан
+		if s.breakers[backend] != b || b.state != Open {
+			s.mu.Unlock()
+			return
+		}
+		b.state = HalfOpen
+		b.since = time.Now()
+		s.mu.Unlock()
+
+		slog.Info("Circuit breaker half-open", slog.String("backend", backend))
+		s.onChange()
+	})
+}
+
+// record добавляет итог запроса в окно
+func (b *breaker) record(now time.Time, window time.Duration, failed bool) {
+	if failed {
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+
+	width := window / buckets
+	start := now.Truncate(width)
+	cur := &b.window[int(start.UnixNano()/int64(width))%buckets]
+	if !cur.start.Equal(start) {
+		*cur = bucket{start: start}
+	}
+	cur.total++
+	if failed {
+		cur.failed++
+	}
+}
+
+// counts возвращает число запросов и отказов за окно
+func (b *breaker) counts(now time.Time, window time.Duration) (total, failed int) {
+	for _, c := range b.window {
+		if now.Sub(c.start) < window {
+			total += c.total
+			failed += c.failed
+		}
+	}
+	return total, failed
+}
+
+func normalize(cfg Config) Config {
+	// Окно делится на buckets корзин; корзина нулевой ширины сломала бы record
+	if cfg.Window/buckets <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 1
+	}
+	return cfg
+}
@@ -0,0 +1,185 @@
+package breaker
+
+import (
+	"errors"
+	"load-balancer/internal/backend"
+	"load-balancer/internal/balancer"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	failure = balancer.Result{Err: errors.New("connection refused")}
+	success = balancer.Result{Status: http.StatusOK}
+)
+
+func TestConsecutiveFailures(t *testing.T) {
+	changed := make(chan struct{}, 4)
+	s := New(Config{ConsecutiveFailures: 3, OpenTimeout: 20 * time.Millisecond}, func() { changed <- struct{}{} })
+
+	s.Observe("a", failure)
+	s.Observe("a", failure)
+	s.Observe("a", success) // Успех сбрасывает счетчик
+	s.Observe("a", failure)
+	s.Observe("a", failure)
+	if s.Excluded("a") {
+		t.Fatal("opened before threshold")
+	}
+
+	s.Observe("a", failure)
+	if !s.Excluded("a") {
+		t.Fatal("expected open after 3 consecutive failures")
+	}
+	<-changed
+
+	// После OpenTimeout - полуоткрытое состояние с одним пробным запросом
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("breaker did not become half-open")
+	}
+	if s.State("a") != HalfOpen {
+		t.Fatalf("state = %s, want half-open", s.State("a"))
+	}
+	if !s.Admit("a") {
+		t.Fatal("probe rejected")
+	}
+	if s.Admit("a") {
+		t.Fatal("second concurrent probe admitted")
+	}
+
+	s.Observe("a", success)
+	if s.State("a") != Closed {
+		t.Fatalf("state = %s, want closed", s.State("a"))
+	}
+}
+
+func TestHalfOpenFailureReopens(t *testing.T) {
+	s := New(Config{ConsecutiveFailures: 1, OpenTimeout: time.Millisecond}, nil)
+
+	s.Observe("a", failure)
+	deadline := time.Now().Add(time.Second)
+	for s.State("a") != HalfOpen {
+		if time.Now().After(deadline) {
+			t.Fatal("breaker did not become half-open")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.Admit("a")
+	s.Observe("a", failure)
+	if s.State("a") != Open {
+		t.Fatalf("state = %s, want open", s.State("a"))
+	}
+}
+
+func TestHalfOpenIgnoresEarlierRequests(t *testing.T) {
+	s := New(Config{ConsecutiveFailures: 1, OpenTimeout: time.Millisecond}, nil)
+
+	// Медленный запрос начат, пока breaker был закрыт
+	slowStart := time.Now()
+	s.Observe("a", failure)
+	deadline := time.Now().Add(time.Second)
+	for s.State("a") != HalfOpen {
+		if time.Now().After(deadline) {
+			t.Fatal("breaker did not become half-open")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if !s.Admit("a") {
+		t.Fatal("probe rejected")
+	}
+	probeStart := time.Now()
+
+	// Итог медленного запроса не освобождает слот пробы и не меняет состояние
+	s.Observe("a", balancer.Result{Start: slowStart, Status: http.StatusOK})
+	if s.State("a") != HalfOpen {
+		t.Fatalf("state = %s after earlier request, want half-open", s.State("a"))
+	}
+	if s.Admit("a") {
+		t.Fatal("earlier request released the probe slot")
+	}
+
+	s.Observe("a", balancer.Result{Start: probeStart, Err: errors.New("timeout")})
+	if s.State("a") != Open {
+		t.Fatalf("state = %s after failed probe, want open", s.State("a"))
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	s := New(Config{ErrorRatePercent: 50, MinRequests: 10, Window: time.Minute}, nil)
+
+	for i := 0; i < 4; i++ {
+		s.Observe("a", success)
+		s.Observe("a", balancer.Result{Status: http.StatusServiceUnavailable})
+	}
+	if s.Excluded("a") {
+		t.Fatal("opened before min requests")
+	}
+
+	s.Observe("a", success)
+	s.Observe("a", failure)
+	if !s.Excluded("a") {
+		t.Fatal("expected open at 50% error rate")
+	}
+	if s.Excluded("b") {
+		t.Fatal("unrelated backend excluded")
+	}
+}
+
+func TestLeastConnectionsSkipsBreaker(t *testing.T) {
+	stats := balancer.NewStats()
+	ab := balancer.NewAtomicBalancer(balancer.NewLeastConnections(nil, stats), stats)
+	s := New(Config{ConsecutiveFailures: 1, OpenTimeout: 10 * time.Millisecond}, ab.Refresh)
+	ab.AddFilter(s)
+	ab.Update([]backend.Backend{{URL: "a"}, {URL: "b"}, {URL: "c"}})
+
+	// b и c заняты, поэтому least-connections предпочитает быстро отказывающий a
+	for i := 0; i < 5; i++ {
+		stats.Acquire("b")
+		stats.Acquire("c")
+	}
+
+	next := func() string {
+		t.Helper()
+		b, err := ab.Next("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	if b := next(); b != "a" {
+		t.Fatalf("first pick = %s, want a", b)
+	}
+	ab.Done("a", failure)
+	for i := 0; i < 10; i++ {
+		b := next()
+		if b == "a" {
+			t.Fatal("backend with open breaker selected")
+		}
+		ab.Done(b, success)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for s.State("a") != HalfOpen {
+		if time.Now().After(deadline) {
+			t.Fatal("breaker did not become half-open")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Пробный запрос занимает единственный слот; остальные уходят на b и c, а не получают 503
+	if b := next(); b != "a" {
+		t.Fatalf("probe pick = %s, want a", b)
+	}
+	for i := 0; i < 10; i++ {
+		b := next()
+		if b == "a" {
+			t.Fatal("second concurrent probe admitted")
+		}
+		ab.Done(b, success)
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"load-balancer/internal/backend"
+)
+
+// UnmarshalYAML позволяет задавать бэкенд как строкой ("http://host:port"),
+// так и объектом с весом ({url: ..., weight: ...})
+func (b *BackendConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		b.URL = value.Value
+		return nil
+	}
+
+	type plain BackendConfig // Без метода UnmarshalYAML, чтобы избежать рекурсии
+	var p plain
+	if err := value.Decode(&p); err != nil {
+		return err
+	}
+	*b = BackendConfig(p)
+	return nil
+}
+
+// HealthProbeOverrides возвращает итоговые параметры проверки бэкендов,
+// для которых задано переопределение (URL -> параметры)
+func (c *Config) HealthProbeOverrides() map[string]HealthProbeConfig {
+	overrides := make(map[string]HealthProbeConfig)
+	for _, list := range [][]BackendConfig{c.Backends, c.Backup} {
+		for _, b := range list {
+			if b.HealthCheck != nil {
+				overrides[b.URL] = c.HealthCheck.HealthProbeConfig.merge(*b.HealthCheck)
+			}
+		}
+	}
+	return overrides
+}
+
+// merge возвращает параметры p, замененные непустыми полями o
+func (p HealthProbeConfig) merge(o HealthProbeConfig) HealthProbeConfig {
+	if o.Type != "" {
+		p.Type = o.Type
+	}
+	if o.Path != "" {
+		p.Path = o.Path
+	}
+	if o.Method != "" {
+		p.Method = o.Method
+	}
+	if o.Port != "" {
+		p.Port = o.Port
+	}
+	if len(o.Headers) > 0 {
+		headers := make(map[string]string, len(p.Headers)+len(o.Headers))
+		for k, v := range p.Headers {
+			headers[k] = v
+		}
+		for k, v := range o.Headers {
+			headers[k] = v
+		}
+		p.Headers = headers
+	}
+	if len(o.ExpectedStatus) > 0 {
+		p.ExpectedStatus = o.ExpectedStatus
+	}
+	if o.BodyContains != "" {
+		p.BodyContains = o.BodyContains
+	}
+	if o.BodyRegex != "" {
+		p.BodyRegex = o.BodyRegex
+	}
+	if o.Send != "" {
+		p.Send = o.Send
+	}
+	if o.Expect != "" {
+		p.Expect = o.Expect
+	}
+	if o.GRPCService != "" {
+		p.GRPCService = o.GRPCService
+	}
+	return p
+}
+
+// UpstreamTLSOverrides возвращает итоговые параметры TLS бэкендов,
+// для которых задано переопределение proxy.tls (URL -> параметры)
+func (c *Config) UpstreamTLSOverrides() map[string]UpstreamTLSConfig {
+	overrides := make(map[string]UpstreamTLSConfig)
+	for _, list := range [][]BackendConfig{c.Backends, c.Backup} {
+		for _, b := range list {
+			if b.TLS != nil {
+				overrides[b.URL] = c.Proxy.TLS.merge(*b.TLS)
+			}
+		}
+	}
+	return overrides
+}
+
+// merge возвращает параметры t, замененные непустыми полями o
+func (t UpstreamTLSConfig) merge(o UpstreamTLSConfig) UpstreamTLSConfig {
+	if o.CAFile != "" {
+		t.CAFile = o.CAFile
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		t.CertFile = o.CertFile
+		t.KeyFile = o.KeyFile
+	}
+	if o.ServerName != "" {
+		t.ServerName = o.ServerName
+	}
+	if o.InsecureSkipVerify {
+		t.InsecureSkipVerify = true
+	}
+	return t
+}
+
+// BackendList возвращает бэкенды всех уровней в виде, понятном балансировщику
+func (c *Config) BackendList() []backend.Backend {
+	list := make([]backend.Backend, 0, len(c.Backends)+len(c.Backup))
+	for _, b := range c.Backends {
+		list = append(list, backend.Backend{URL: b.URL, Weight: b.Weight, Priority: backend.PriorityPrimary})
+	}
+	for _, b := range c.Backup {
+		list = append(list, backend.Backend{URL: b.URL, Weight: b.Weight, Priority: backend.PriorityBackup})
+	}
+	return list
+}
@@ -1,7 +1,7 @@
 package config
 
 import (
-	"github.com/fsnotify/fsnotify"
+	"context"
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
 	"log"
@@ -15,6 +15,7 @@ var (
 	current     *Config
 	mu          sync.RWMutex
 	subscribers []func(*Config)
+	reloadHooks []func(error) // Итоги перезагрузок, в т.ч. неудачных
 	configPath  string
 )
 
@@ -37,6 +38,17 @@ func Load(path string) (*Config, error) {
 		nil
 }
 
+// Parse загружает конфигурацию из файла и применяет значения по умолчанию,
+// не меняя текущую конфигурацию (например, для проверки файла перед выкладкой)
+func Parse(path string) (*Config, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	loadDefaultValues(cfg)
+	return cfg, nil
+}
+
 // Get возвращает копию текущей конфигурации
 func Get() *Config {
 	mu.RLock()
@@ -51,6 +63,23 @@ func Subscribe(callback func(*Config)) {
 	subscribers = append(subscribers, callback)
 }
 
+// OnReload добавляет обработчик итога каждой перезагрузки: nil - успех, иначе ошибка
+func OnReload(callback func(error)) {
+	mu.Lock()
+	defer mu.Unlock()
+	reloadHooks = append(reloadHooks, callback)
+}
+
+// reloaded сообщает итог перезагрузки обработчикам OnReload
+func reloaded(err error) {
+	mu.RLock()
+	hooks := reloadHooks
+	mu.RUnlock()
+	for _, h := range hooks {
+		h(err)
+	}
+}
+
 // Init инициализирует конфиг и запускает наблюдение
 func Init(pathOptional ...string) error {
 	templatePath := "configs/config.template.yaml"
@@ -85,68 +114,45 @@ func Init(pathOptional ...string) error {
 	current = cfg
 	mu.Unlock()
 
-	go watch(configPath, templatePath)
+	watch(configPath, templatePath)
 	return nil
 }
 
-// Watch следит за изменением файла шаблона или .env и обновляет конфиг
+// watch следит за изменением файла шаблона или .env и обновляет конфиг
 func watch(outputPath, templatePath string) {
-	watcher, err := fsnotify.NewWatcher()
+	err := WatchFiles(context.Background(), []string{templatePath, ".env"}, 500*time.Millisecond, func() {
+		reload(outputPath, templatePath)
+	})
 	if err != nil {
-		log.Println("failed to create watcher:", err)
-		return
+		log.Println("failed to watch config files:", err)
 	}
-	defer watcher.Close()
+}
 
-	if err = watcher.Add(templatePath); err != nil {
-		log.Println("failed to watch (template) config file:", err)
+// reload перечитывает шаблон и сообщает подписчикам новую конфигурацию
+func reload(outputPath, templatePath string) {
+	err := renderConfigFromTemplate(templatePath, outputPath)
+	if err != nil {
+		log.Printf("failed to render config from template: %v\n", err)
+		reloaded(err)
 		return
 	}
 
-	if err = watcher.Add(".env"); err != nil {
-		log.Println("warn: failed to watch .env:", err)
+	// Загружаем новый config.yaml
+	cfg, err := Load(outputPath)
+	if err != nil {
+		log.Printf("failed to reload config: %v\n", err)
+		reloaded(err)
 		return
 	}
-
-	var debounce *time.Timer
-
-	for {
-		select {
-		case event, ok := <-watcher.Events:
-			if !ok {
-				return
-			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				// debounce, чтобы не перезагружать конфиг многократно при одной операции
-				if debounce != nil {
-					debounce.Stop()
-				}
-				debounce = time.AfterFunc(500*time.Millisecond, func() {
-					err := renderConfigFromTemplate(templatePath, outputPath)
-					if err != nil {
-						log.Printf("failed to render config from template: %v\n", err)
-						return
-					}
-
-					// Загружаем новый config.yaml
-					cfg, err := Load(outputPath)
-					if err != nil {
-						log.Printf("failed to reload config: %v\n", err)
-						return
-					}
-					mu.Lock()
-					current = cfg
-					for _, s := range subscribers {
-						go s(cfg)
-					}
-					mu.Unlock()
-					log.Println("config reloaded (template change detected)")
-				})
-			}
-		case err := <-watcher.Errors:
-			log.Printf("watch error: %v\n", err)
-		}
+	mu.Lock()
+	loadDefaultValues(cfg)
+	current = cfg
+	for _, s := range subscribers {
+		go s(cfg)
 	}
+	mu.Unlock()
+	reloaded(nil)
+	log.Println("config reloaded (template change detected)")
 }
 
 // renderConfigFromTemplate обрабатывает шаблон и записывает YAML
@@ -1,6 +1,9 @@
 package config
 
-import "time"
+import (
+	"load-balancer/internal/backend"
+	"time"
+)
 
 type option func(*Config)
 
@@ -23,6 +26,12 @@ func withDefaultServer() option {
 		if cfg.Server.WriteTimeout == 0 {
 			cfg.Server.WriteTimeout = 10 * time.Second
 		}
+		if cfg.Server.TLS.Port == "" {
+			cfg.Server.TLS.Port = "8443"
+		}
+		if cfg.Server.TLS.MinVersion == "" {
+			cfg.Server.TLS.MinVersion = "1.2"
+		}
 	}
 }
 
@@ -37,6 +46,34 @@ func withDefaultHealthCheck() option {
 		if cfg.HealthCheck.Path == "" {
 			cfg.HealthCheck.Path = "/health"
 		}
+		// По умолчанию состояние меняет одна проверка, как до появления порогов
+		if cfg.HealthCheck.HealthyThreshold <= 0 {
+			cfg.HealthCheck.HealthyThreshold = 1
+		}
+		if cfg.HealthCheck.UnhealthyThreshold <= 0 {
+			cfg.HealthCheck.UnhealthyThreshold = 1
+		}
+		if cfg.HealthCheck.Jitter == 0 {
+			cfg.HealthCheck.Jitter = cfg.HealthCheck.IntervalSeconds / 10
+		}
+		if cfg.HealthCheck.MaxConcurrentProbes <= 0 {
+			cfg.HealthCheck.MaxConcurrentProbes = 64
+		}
+	}
+}
+
+func withDefaultBackends() option {
+	return func(cfg *Config) {
+		for _, list := range [][]BackendConfig{cfg.Backends, cfg.Backup} {
+			for i := range list {
+				if list[i].Weight <= 0 {
+					list[i].Weight = backend.DefaultWeight
+				}
+			}
+		}
+		if cfg.Failover.MinHealthy <= 0 {
+			cfg.Failover.MinHealthy = 1
+		}
 	}
 }
 
@@ -51,6 +88,179 @@ func withDefaultRateLimiter() option {
 	}
 }
 
+func withDefaultSticky() option {
+	return func(cfg *Config) {
+		if cfg.Sticky.CookieName == "" {
+			cfg.Sticky.CookieName = "lb_affinity"
+		}
+		if cfg.Sticky.TTL == 0 {
+			cfg.Sticky.TTL = time.Hour
+		}
+	}
+}
+
+func withDefaultSlowStart() option {
+	return func(cfg *Config) {
+		if cfg.SlowStart.MinWeightPercent <= 0 {
+			cfg.SlowStart.MinWeightPercent = 10
+		}
+		if cfg.SlowStart.Aggression <= 0 {
+			cfg.SlowStart.Aggression = 1
+		}
+	}
+}
+
+func withDefaultProxy() option {
+	return func(cfg *Config) {
+		if cfg.Proxy.MaxIdleConns == 0 {
+			cfg.Proxy.MaxIdleConns = 1000
+		}
+		if cfg.Proxy.MaxIdleConnsPerHost == 0 {
+			cfg.Proxy.MaxIdleConnsPerHost = 100
+		}
+		if cfg.Proxy.IdleConnTimeout == 0 {
+			cfg.Proxy.IdleConnTimeout = 90 * time.Second
+		}
+		if cfg.Proxy.DialTimeout == 0 {
+			cfg.Proxy.DialTimeout = 5 * time.Second
+		}
+		if cfg.Proxy.KeepAlive == 0 {
+			cfg.Proxy.KeepAlive = 30 * time.Second
+		}
+		if cfg.Proxy.TLSHandshakeTimeout == 0 {
+			cfg.Proxy.TLSHandshakeTimeout = 10 * time.Second
+		}
+		if cfg.Proxy.ResponseHeaderTimeout == 0 {
+			cfg.Proxy.ResponseHeaderTimeout = 30 * time.Second
+		}
+	}
+}
+
+func withDefaultRetry() option {
+	return func(cfg *Config) {
+		if cfg.Retry.MaxAttempts <= 0 {
+			cfg.Retry.MaxAttempts = 1
+		}
+		if cfg.Retry.RetryOn == nil {
+			cfg.Retry.RetryOn = []string{"connect-error", "502", "503", "504"}
+		}
+		if cfg.Retry.MaxBodyBytes <= 0 {
+			cfg.Retry.MaxBodyBytes = 1 << 20
+		}
+	}
+}
+
+func withDefaultBreaker() option {
+	return func(cfg *Config) {
+		// Явный 0 выключает условие размыкания, значение по умолчанию - только для незаданных
+		if cfg.Breaker.ConsecutiveFailures == nil {
+			cfg.Breaker.ConsecutiveFailures = ptr(5)
+		}
+		if cfg.Breaker.ErrorRatePercent == nil {
+			cfg.Breaker.ErrorRatePercent = ptr(50)
+		}
+		if cfg.Breaker.MinRequests <= 0 {
+			cfg.Breaker.MinRequests = 20
+		}
+		if cfg.Breaker.Window <= 0 {
+			cfg.Breaker.Window = 10 * time.Second
+		}
+		if cfg.Breaker.OpenTimeout <= 0 {
+			cfg.Breaker.OpenTimeout = 30 * time.Second
+		}
+		if cfg.Breaker.HalfOpenProbes <= 0 {
+			cfg.Breaker.HalfOpenProbes = 1
+		}
+	}
+}
+
+func withDefaultOutlier() option {
+	return func(cfg *Config) {
+		// Явный 0 выключает условие исключения, значение по умолчанию - только для незаданных
+		if cfg.Outlier.ConsecutiveErrors == nil {
+			cfg.Outlier.ConsecutiveErrors = ptr(5)
+		}
+		if cfg.Outlier.ErrorRatePercent == nil {
+			cfg.Outlier.ErrorRatePercent = ptr(50)
+		}
+		if cfg.Outlier.MinRequests <= 0 {
+			cfg.Outlier.MinRequests = 10
+		}
+		if cfg.Outlier.Interval <= 0 {
+			cfg.Outlier.Interval = 10 * time.Second
+		}
+		if cfg.Outlier.BaseEjectionTime <= 0 {
+			cfg.Outlier.BaseEjectionTime = 30 * time.Second
+		}
+		if cfg.Outlier.MaxEjectionTime <= 0 {
+			cfg.Outlier.MaxEjectionTime = 5 * time.Minute
+		}
+		if cfg.Outlier.MaxEjectionPercent <= 0 {
+			cfg.Outlier.MaxEjectionPercent = 50
+		}
+	}
+}
+
+func withDefaultAdmin() option {
+	return func(cfg *Config) {
+		if cfg.Admin.Port == "" {
+			cfg.Admin.Port = "9000"
+		}
+		if cfg.Admin.DrainTimeout <= 0 {
+			cfg.Admin.DrainTimeout = 30 * time.Second
+		}
+	}
+}
+
+func withDefaultMetrics() option {
+	return func(cfg *Config) {
+		if cfg.Metrics.Port == "" {
+			cfg.Metrics.Port = "9091"
+		}
+		if cfg.Metrics.Path == "" {
+			cfg.Metrics.Path = "/metrics"
+		}
+	}
+}
+
+func withDefaultTracing() option {
+	return func(cfg *Config) {
+		if cfg.Tracing.Exporter == "" {
+			cfg.Tracing.Exporter = "otlp"
+		}
+		if cfg.Tracing.SampleRatio <= 0 || cfg.Tracing.SampleRatio > 1 {
+			cfg.Tracing.SampleRatio = 1
+		}
+		if cfg.Tracing.ServiceName == "" {
+			cfg.Tracing.ServiceName = "load-balancer"
+		}
+	}
+}
+
+func withDefaultAccessLog() option {
+	return func(cfg *Config) {
+		if cfg.AccessLog.Format == "" {
+			cfg.AccessLog.Format = "combined"
+		}
+	}
+}
+
+func withDefaultLog() option {
+	return func(cfg *Config) {
+		if cfg.LogLevel == "" {
+			cfg.LogLevel = "info"
+		}
+		if cfg.LogFormat == "" {
+			cfg.LogFormat = "pretty"
+		}
+	}
+}
+
+// ptr возвращает указатель на значение по умолчанию для необязательных полей
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func useDefault(cfg *Config, options ...option) {
 	for _, op := range options {
 		op(cfg)
@@ -61,8 +271,20 @@ func loadDefaultValues(cfg *Config) {
 	useDefault(
 		cfg,
 		withDefaultServer(),
+		withDefaultBackends(),
 		withDefaultHealthCheck(),
 		withDefaultStrategy(),
 		withDefaultRateLimiter(),
+		withDefaultSticky(),
+		withDefaultSlowStart(),
+		withDefaultProxy(),
+		withDefaultRetry(),
+		withDefaultBreaker(),
+		withDefaultOutlier(),
+		withDefaultAdmin(),
+		withDefaultMetrics(),
+		withDefaultTracing(),
+		withDefaultAccessLog(),
+		withDefaultLog(),
 	)
 }
@@ -5,23 +5,197 @@ import "time"
 type Config struct {
 	Server      ServerSettings    `yaml:"server"`
 	Strategy    string            `yaml:"strategy"`
-	Backends    []string          `yaml:"backends"`
+	Backends    []BackendConfig   `yaml:"backends"`        // Основной (primary) уровень
+	Backup      []BackendConfig   `yaml:"backup_backends"` // Резервный (backup) уровень
+	Failover    FailoverConfig    `yaml:"failover"`
+	SlowStart   SlowStartConfig   `yaml:"slow_start"`
+	Proxy       ProxyConfig       `yaml:"proxy"`
+	Retry       RetryConfig       `yaml:"retry"`
+	Breaker     BreakerConfig     `yaml:"circuit_breaker"`
+	Outlier     OutlierConfig     `yaml:"outlier_detection"`
 	HealthCheck HealthCheckConfig `yaml:"health_check"`
 	RateLimiter RateLimiterConfig `yaml:"rate_limiter"`
-	LogFile     string            `yaml:"log_file"`  // Путь к файлу логов
-	LogLevel    string            `yaml:"log_level"` // e.g., "debug", "info", "error"
+	Sticky      StickyConfig      `yaml:"sticky_session"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	AccessLog   AccessLogConfig   `yaml:"access_log"`
+	RequestID   RequestIDConfig   `yaml:"request_id"`
+	LogFile     string            `yaml:"log_file"`   // Путь к файлу журнала запросов; пусто - stdout
+	LogLevel    string            `yaml:"log_level"`  // debug | info | warn | error; меняется без перезапуска
+	LogFormat   string            `yaml:"log_format"` // json | text | pretty
+	LogSource   bool              `yaml:"log_source"` // Добавлять в записи файл и строку вызова
 }
 
 type ServerSettings struct {
 	Port         string        `yaml:"port"`
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+	TLS          TLSConfig     `yaml:"tls"`
+}
+
+// TLSConfig HTTPS на отдельном порту балансировщика
+type TLSConfig struct {
+	Enabled      bool                `yaml:"enabled"`
+	Port         string              `yaml:"port"`
+	Certificates []CertificateConfig `yaml:"certificates"`  // Первый - по умолчанию, остальные выбираются по SNI
+	MinVersion   string              `yaml:"min_version"`   // 1.0 | 1.1 | 1.2 | 1.3
+	CipherSuites []string            `yaml:"cipher_suites"` // Имена из crypto/tls, только для TLS 1.2 и ниже; пусто - набор Go
+	DisableHTTP2 bool                `yaml:"disable_http2"` // Не предлагать h2 в ALPN
+	RedirectHTTP bool                `yaml:"redirect_http"` // Порт server.port отвечает редиректом на HTTPS
+}
+
+// CertificateConfig файлы сертификата (PEM, с цепочкой) и ключа; перечитываются при изменении
+type CertificateConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type BackendConfig struct {
+	URL         string             `yaml:"url"`
+	Weight      int                `yaml:"weight"`       // Относительный вес для взвешенных стратегий, по умолчанию 1
+	HealthCheck *HealthProbeConfig `yaml:"health_check"` // Переопределение параметров проверки для бэкенда
+	TLS         *UpstreamTLSConfig `yaml:"tls"`          // Переопределение proxy.tls для бэкенда
+}
+
+type FailoverConfig struct {
+	// Минимум живых бэкендов, при котором уровень обслуживает трафик.
+	// Если в основном уровне живых меньше, трафик уходит в резервный.
+	MinHealthy int `yaml:"min_healthy"`
+}
+
+type SlowStartConfig struct {
+	Window           time.Duration `yaml:"window"`             // Время разгона восстановившегося бэкенда; 0 - выключено
+	MinWeightPercent int           `yaml:"min_weight_percent"` // Начальная доля веса в процентах
+	Aggression       float64       `yaml:"aggression"`         // Кривая разгона: 1 - линейно, > 1 - быстрее в начале
+}
+
+// ProxyConfig параметры соединений балансировщика с бэкендами
+type ProxyConfig struct {
+	MaxIdleConns          int               `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost   int               `yaml:"max_idle_conns_per_host"`
+	IdleConnTimeout       time.Duration     `yaml:"idle_conn_timeout"`
+	DialTimeout           time.Duration     `yaml:"dial_timeout"`
+	KeepAlive             time.Duration     `yaml:"keep_alive"`
+	TLSHandshakeTimeout   time.Duration     `yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration     `yaml:"response_header_timeout"`
+	DisableKeepAlives     bool              `yaml:"disable_keep_alives"`
+	TLS                   UpstreamTLSConfig `yaml:"tls"`
+}
+
+// UpstreamTLSConfig параметры TLS соединений с бэкендами https, в т.ч. для проверок здоровья.
+// Задаются в proxy.tls и переопределяются для отдельного бэкенда (пустые поля наследуются).
+type UpstreamTLSConfig struct {
+	CAFile             string `yaml:"ca_file"`              // PEM-бандл корневых сертификатов; пусто - системные
+	CertFile           string `yaml:"cert_file"`            // Клиентский сертификат для mTLS
+	KeyFile            string `yaml:"key_file"`             // Ключ клиентского сертификата
+	ServerName         string `yaml:"server_name"`          // SNI и имя в сертификате; пусто - хост бэкенда
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // Не проверять сертификат бэкенда
+}
+
+type RetryConfig struct {
+	MaxAttempts  int           `yaml:"max_attempts"`   // Всего попыток, включая первую; 1 - без повторов
+	RetryOn      []string      `yaml:"retry_on"`       // "connect-error" и/или коды статусов: "502", "503", "504"
+	Methods      []string      `yaml:"methods"`        // Пусто - только идемпотентные методы
+	MaxBodyBytes int64         `yaml:"max_body_bytes"` // Запросы с телом больше лимита не повторяются
+	Budget       time.Duration `yaml:"budget"`         // Время с начала запроса, после которого повторы не начинаются
+}
+
+// BreakerConfig circuit breaker для каждого бэкенда по итогам реальных запросов
+type BreakerConfig struct {
+	Enabled             bool          `yaml:"enabled"`
+	ConsecutiveFailures *int          `yaml:"consecutive_failures"` // Отказов подряд для размыкания; 0 - не учитывать
+	ErrorRatePercent    *int          `yaml:"error_rate_percent"`   // Доля отказов в окне для размыкания; 0 - не учитывать
+	MinRequests         int           `yaml:"min_requests"`         // Минимум запросов в окне для учета доли отказов
+	Window              time.Duration `yaml:"window"`
+	OpenTimeout         time.Duration `yaml:"open_timeout"`     // Время до пробных запросов
+	HalfOpenProbes      int           `yaml:"half_open_probes"` // Одновременных пробных запросов
+}
+
+// OutlierConfig пассивная проверка здоровья по проксированным запросам
+type OutlierConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	ConsecutiveErrors  *int          `yaml:"consecutive_errors"` // Ошибок соединения/таймаутов подряд; 0 - не учитывать
+	ErrorRatePercent   *int          `yaml:"error_rate_percent"` // Доля ответов 5xx за интервал; 0 - не учитывать
+	MinRequests        int           `yaml:"min_requests"`       // Минимум запросов за интервал для учета доли 5xx
+	Interval           time.Duration `yaml:"interval"`
+	BaseEjectionTime   time.Duration `yaml:"base_ejection_time"` // Удваивается с каждым исключением подряд
+	MaxEjectionTime    time.Duration `yaml:"max_ejection_time"`
+	MaxEjectionPercent int           `yaml:"max_ejection_percent"` // Максимум одновременно исключенных, %
 }
 
 type HealthCheckConfig struct {
 	IntervalSeconds time.Duration `yaml:"interval_seconds"`
 	TimeoutSeconds  time.Duration `yaml:"timeout_seconds"`
-	Path            string        `yaml:"path"` // Path for health check, e.g. /health
+	// Проверок подряд для смены состояния бэкенда; по умолчанию 1
+	HealthyThreshold   int `yaml:"healthy_threshold"`
+	UnhealthyThreshold int `yaml:"unhealthy_threshold"`
+	// Случайное смещение проверок каждого бэкенда, чтобы проверки не шли одновременно
+	Jitter              time.Duration `yaml:"jitter"`
+	MaxConcurrentProbes int           `yaml:"max_concurrent_probes"`
+
+	HealthProbeConfig `yaml:",inline"`
+}
+
+// HealthProbeConfig параметры запроса проверки. Задаются в health_check
+// и переопределяются для отдельного бэкенда (пустые поля наследуются).
+type HealthProbeConfig struct {
+	Type           string            `yaml:"type"` // http (по умолчанию) | tcp | grpc
+	Path           string            `yaml:"path"` // Path for health check, e.g. /health
+	Method         string            `yaml:"method"`
+	Port           string            `yaml:"port"`            // Порт проверки, если отличается от порта бэкенда
+	Headers        map[string]string `yaml:"headers"`         // Например, Host или токен авторизации
+	ExpectedStatus []string          `yaml:"expected_status"` // "200", "200-299", "2xx"; пусто - только 200
+	BodyContains   string            `yaml:"body_contains"`
+	BodyRegex      string            `yaml:"body_regex"`
+	Send           string            `yaml:"send"`         // tcp: данные после соединения
+	Expect         string            `yaml:"expect"`       // tcp: подстрока в ответе
+	GRPCService    string            `yaml:"grpc_service"` // grpc: имя сервиса для grpc.health.v1
+}
+
+type StickyConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	CookieName string        `yaml:"cookie_name"`
+	Secret     string        `yaml:"secret"` // Ключ HMAC для подписи cookie; должен совпадать у всех реплик
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+// AdminConfig API администратора на отдельном порту
+type AdminConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Port         string        `yaml:"port"`
+	Token        string        `yaml:"token"`         // Bearer-токен; пусто - без авторизации
+	DrainTimeout time.Duration `yaml:"drain_timeout"` // Таймаут вывода бэкенда по умолчанию
+}
+
+// MetricsConfig метрики Prometheus на отдельном порту
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Port    string `yaml:"port"`
+	Path    string `yaml:"path"`
+}
+
+// TracingConfig трассировка OpenTelemetry
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Exporter    string  `yaml:"exporter"`     // otlp | stdout
+	Endpoint    string  `yaml:"endpoint"`     // otlp: host:port коллектора (OTLP/HTTP)
+	Insecure    bool    `yaml:"insecure"`     // otlp: без TLS
+	SampleRatio float64 `yaml:"sample_ratio"` // Доля трассируемых запросов без родительского спана
+	ServiceName string  `yaml:"service_name"`
+}
+
+// AccessLogConfig журнал запросов; пишется в log_file
+type AccessLogConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Format  string `yaml:"format"` // json | combined | logfmt
+}
+
+// RequestIDConfig X-Request-ID назначается каждому проксируемому запросу
+type RequestIDConfig struct {
+	// Адреса и подсети (например, внешний прокси), чей входящий X-Request-ID принимается;
+	// от остальных клиентов ID заменяется новым
+	TrustedSources []string `yaml:"trusted_sources"`
 }
 
 type RateLimiterConfig struct {
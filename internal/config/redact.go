@@ -0,0 +1,61 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue заменяет секреты при выводе конфигурации
+const redactedValue = "<redacted>"
+
+// Redacted возвращает конфигурацию в виде дерева с ключами как в YAML,
+// секреты (ключ sticky-сессий, токен администратора, заголовки health check) заменены
+func Redacted(cfg *Config) (map[string]any, error) {
+	c := *cfg
+	if c.Sticky.Secret != "" {
+		c.Sticky.Secret = redactedValue
+	}
+	if c.Admin.Token != "" {
+		c.Admin.Token = redactedValue
+	}
+	c.HealthCheck.Headers = redactHeaders(c.HealthCheck.Headers)
+	c.Backends = redactBackends(c.Backends)
+	c.Backup = redactBackends(c.Backup)
+
+	data, err := yaml.Marshal(&c)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// redactBackends возвращает копию списка с замененными заголовками переопределений проверки
+func redactBackends(list []BackendConfig) []BackendConfig {
+	if list == nil {
+		return nil
+	}
+	out := append([]BackendConfig(nil), list...)
+	for i, b := range out {
+		if b.HealthCheck != nil && len(b.HealthCheck.Headers) > 0 {
+			probe := *b.HealthCheck
+			probe.Headers = redactHeaders(probe.Headers)
+			out[i].HealthCheck = &probe
+		}
+	}
+	return out
+}
+
+// redactHeaders заменяет значения заголовков: в них передают токены авторизации
+func redactHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string]string, len(headers))
+	for k := range headers {
+		out[k] = redactedValue
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// WatchFiles вызывает onChange, когда меняется любой из файлов paths.
+// Наблюдение идет за каталогами файлов, поэтому замечается и атомарная замена
+// файла через rename. События в пределах debounce объединяются в один вызов.
+// Ошибка возвращается, если наблюдение не удалось начать; остановка - по отмене ctx.
+func WatchFiles(ctx context.Context, paths []string, debounce time.Duration, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		file := filepath.Clean(p)
+		files = append(files, file)
+		dir := filepath.Dir(file)
+		if slices.Contains(watcher.WatchList(), dir) {
+			continue
+		}
+		if err = watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				if !slices.Contains(files, filepath.Clean(event.Name)) {
+					continue
+				}
+				// debounce, чтобы не вызывать onChange многократно при одной операции
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(debounce, onChange)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("File watch error", slog.String("error", err.Error()))
+			}
+		}
+	}()
+	return nil
+}
@@ -1,19 +1,26 @@
 /*
 Пакет health реализует:
 - Активные проверки здоровья бэкендов
-- Механизм callback при изменении состояния
+- Пороги смены состояния (несколько успехов/отказов подряд) против флаппинга
+- Механизм callback при изменении списка живых
+- События переходов бэкендов и ограниченную историю по каждому бэкенду
 - Горячее обновление параметров проверок
-- Параллельное выполнение проверок с таймаутами
+- Независимое расписание проверок каждого бэкенда со случайным смещением
+- Общий транспорт и ограничение числа одновременных проверок
+- TLS и mTLS к бэкендам https: общие параметры и параметры отдельных бэкендов
 */
 
 package health
 
 import (
 	"context"
+	"crypto/tls"
+	"load-balancer/internal/backend"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"slices"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 )
@@ -21,15 +28,27 @@ import (
 // Checker выполняет периодические проверки и обновляет состояние
 type Checker struct {
 	mu       sync.RWMutex
-	backends []string
+	backends []backend.Backend
 
-	interval time.Duration
-	timeout  time.Duration
-	path     string // Путь для health check, например "/health"
+	interval  time.Duration
+	timeout   time.Duration
+	check     Check            // Проверка по умолчанию
+	overrides map[string]Check // Проверки отдельных бэкендов (URL -> проверка)
 
-	OnUpdate func([]string) // Callback для уведомления об изменении списка живых серверов
+	jitter        time.Duration         // Случайное смещение проверок
+	maxConcurrent int                   // Максимум одновременных проверок; 0 - без ограничения
+	conn          *probeConn            // Общие соединения проверок
+	tlsConns      map[string]*probeConn // Соединения бэкендов со своими параметрами TLS
 
-	//healthy map[string]bool
+	OnUpdate         func([]backend.Backend) // Callback для уведомления об изменении списка живых серверов
+	subscribers      []func(Event)           // Обработчики переходов бэкендов
+	probeSubscribers []func(Probe)           // Обработчики каждой проверки
+
+	healthyThreshold   int                      // Успешных проверок подряд, чтобы бэкенд стал живым
+	unhealthyThreshold int                      // Неудачных проверок подряд, чтобы бэкенд стал мертвым
+	states             map[string]*backendState // Состояние бэкендов сохраняется между раундами
+	lastLive           []backend.Backend        // Последний переданный в OnUpdate список; nil - еще не передавался
+	notifyMu           sync.Mutex               // Сериализует уведомления о переходах и списке живых
 
 	// Для управления циклом проверок
 	activeCtx    context.Context    // Контекст текущего активного цикла проверок
@@ -37,50 +56,110 @@ type Checker struct {
 	wg           sync.WaitGroup     // Для ожидания завершения горутины проверок
 }
 
+// backendState результат проверок бэкенда с учетом порогов
+type backendState struct {
+	state     State
+	since     time.Time   // Время последнего перехода
+	successes int         // Успешных проверок подряд
+	failures  int         // Неудачных проверок подряд
+	last      probeResult // Последняя проверка
+	history   []Event     // Последние historySize переходов
+}
+
 func NewChecker(
-	initBackends []string,
+	initBackends []backend.Backend,
 	initInterval, initTimeout time.Duration,
-	initPath string,
-	onUpdate func([]string)) *Checker {
+	initCheck Check,
+	onUpdate func([]backend.Backend)) *Checker {
 	return &Checker{
-		backends: append([]string(nil), initBackends...),
+		conn: newProbeConn(nil),
+
+		backends: append([]backend.Backend(nil), initBackends...),
 		interval: initInterval,
 		timeout:  initTimeout,
-		path:     initPath,
+		check:    initCheck,
 		OnUpdate: onUpdate,
+
+		healthyThreshold:   1,
+		unhealthyThreshold: 1,
+		states:             make(map[string]*backendState),
+	}
+}
+
+// SetThresholds задает, сколько проверок подряд нужно для смены состояния бэкенда.
+// Первая проверка нового бэкенда определяет его состояние сразу.
+func (c *Checker) SetThresholds(healthy, unhealthy int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthyThreshold = max(healthy, 1)
+	c.unhealthyThreshold = max(unhealthy, 1)
+}
+
+// SetScheduling задает случайное смещение проверок и максимум одновременных проверок.
+// Применяется при следующем Start.
+func (c *Checker) SetScheduling(jitter time.Duration, maxConcurrent int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jitter = max(jitter, 0)
+	c.maxConcurrent = max(maxConcurrent, 0)
+}
+
+// SetOverrides задает проверки отдельных бэкендов (URL -> проверка), заменяющие проверку по умолчанию.
+// Применяется при следующем Start.
+func (c *Checker) SetOverrides(overrides map[string]Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overrides = overrides
+}
+
+// SetTLS задает параметры TLS проверок бэкендов https: общие (nil - параметры Go по умолчанию)
+// и для отдельных бэкендов (URL -> параметры). Используются HTTP- и gRPC-проверками.
+// Применяется сразу, в т.ч. к запущенному циклу проверок, чтобы обновленные сертификаты
+// использовались без перезапуска.
+func (c *Checker) SetTLS(def *tls.Config, overrides map[string]*tls.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closeConns()
+	c.conn = newProbeConn(def)
+	c.tlsConns = make(map[string]*probeConn, len(overrides))
+	for url, cfg := range overrides {
+		c.tlsConns[url] = newProbeConn(cfg)
 	}
 }
 
 // UpdateConfig останавливает текущий цикл проверок (если он был запущен),
 // обновляет конфигурацию и рекомендует перезапустить Start.
 func (c *Checker) UpdateConfig(
-	newBackends []string,
+	newBackends []backend.Backend,
 	newInterval, newTimeout time.Duration,
-	newPath string) {
+	newCheck Check) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	slog.Info("HealthChecker: received config update",
-		slog.Any("new_backends", newBackends),
+		slog.Any("new_backends", backend.URLs(newBackends)),
 		slog.Duration("new_interval", newInterval),
 		slog.Duration("new_timeout", newTimeout),
-		slog.String("new_path", newPath))
+		slog.String("new_path", newCheck.Path))
 
 	// Если цикл был активен, сигнализируем ему об остановке
 	if c.activeCancel != nil {
 		slog.Debug("HealthChecker: signaling current check cycle to stop due to config update.")
+		c.activeCancel() // Сигнал на остановку
 	}
 
-	c.activeCancel() // Сигнал на остановку
 	// Не ждем здесь c.wg.Wait(), чтобы не блокировать подписчика конфига надолго.
 	// Вызывающий код (в main) должен будет дождаться остановки перед новым Start.
-	c.backends = append([]string(nil), newBackends...) // Обновляем с копией
+	c.backends = append([]backend.Backend(nil), newBackends...) // Обновляем с копией
 	c.interval = newInterval
 	c.timeout = newTimeout
-	c.path = newPath
+	c.check = newCheck
 }
 
 // Start запускает цикл проверок здоровья. Если уже запущен, ничего не делает.
 // Принимает родительский контекст для общего управления жизненным циклом.
+// Каждый бэкенд проверяется по своему расписанию со случайным смещением до jitter,
+// чтобы проверки всех бэкендов (и всех реплик балансировщика) не совпадали по времени.
 func (c *Checker) Start(parentCtx context.Context) {
 	c.mu.Lock() // Блокируем для проверки и установки activeCancel
 
@@ -98,50 +177,52 @@ func (c *Checker) Start(parentCtx context.Context) {
 	c.activeCtx = ctx
 	c.activeCancel = cancel
 
-	// Копируем текущие параметры под мьютексом, чтобы горутина работала с консистентными данными
-	currentBackends := append([]string(nil), c.backends...)
-	currentInterval := c.interval
-	currentTimeout := c.timeout
-	currentPath := c.path
-	onUpdateCallback := c.OnUpdate
+	// Копируем текущие параметры под мьютексом, чтобы горутины работали с консистентными данными.
+	// Если они изменятся через UpdateConfig, горутины будут остановлены
+	// и запущены новые с актуальными параметрами.
+	round := &round{
+		backends: append([]backend.Backend(nil), c.backends...),
+		interval: c.interval,
+		timeout:  c.timeout,
+		jitter:   c.jitter,
+		checks:   c.checksFor(c.backends),
+		onUpdate: c.OnUpdate,
+	}
+	if c.maxConcurrent > 0 {
+		round.sem = make(chan struct{}, c.maxConcurrent)
+	}
 
-	c.mu.Unlock() // Разблокируем перед запуском горутины
+	// Состояние удаленных из конфигурации бэкендов забывается
+	states := make(map[string]*backendState, len(round.backends))
+	for _, b := range round.backends {
+		if s, ok := c.states[b.URL]; ok {
+			states[b.URL] = s
+		}
+	}
+	c.states = states
 
-	c.wg.Add(1)
-	go func() {
-		defer c.wg.Done()
-		slog.Info(
-			"HealthChecker: health check loop started",
-			slog.Duration("interval", currentInterval),
-			slog.String("path", currentPath),
-			slog.Any("backends_to_check", currentBackends),
-		)
+	c.mu.Unlock() // Разблокируем перед запуском горутин
 
-		// Немедленная первая проверка при старте
-		c.performChecks(currentBackends, currentTimeout, currentPath, onUpdateCallback)
-
-		ticker := time.NewTicker(currentInterval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				// Параметры (backends, timeout, path) были зафиксированы при запуске горутины.
-				// Если они изменятся через UpdateConfig, эта горутина будет остановлена
-				// и запущена новая с актуальными параметрами.
-				c.performChecks(currentBackends, currentTimeout, currentPath, onUpdateCallback)
-				//healthy := c.checkAll()
-				//if c.OnUpdate != nil {
-				//	c.OnUpdate(healthy)
-				//}
-
-				// Если c.activeCancel() был вызван или parentCtx отменен
-			case <-ctx.Done():
-				slog.Info("HealthChecker: health check loop stopping.")
-				return
-			}
-		}
-	}()
+	slog.Info(
+		"HealthChecker: health check loop started",
+		slog.Duration("interval", round.interval),
+		slog.Duration("jitter", round.jitter),
+		slog.String("path", c.check.Path),
+		slog.Any("backends_to_check", backend.URLs(round.backends)),
+	)
+
+	if len(round.backends) == 0 {
+		slog.Debug("HealthChecker: no backends to check.")
+		c.notify(round)
+	}
+
+	for _, b := range round.backends {
+		c.wg.Add(1)
+		go func(b backend.Backend) {
+			defer c.wg.Done()
+			c.schedule(ctx, round, b)
+		}(b)
+	}
 }
 
 func (c *Checker) Stop() {
@@ -159,113 +240,283 @@ func (c *Checker) Stop() {
 
 	c.mu.Unlock()
 	c.wg.Wait()
+	c.mu.Lock()
+	c.closeConns()
+	c.mu.Unlock()
 	slog.Info("HealthChecker: check loop gracefully stopped.")
 }
 
-// performChecks выполняет одну итерацию проверки всех бэкендов
-// Принимает параметры как аргументы, чтобы быть уверенным в их консистентности на момент вызова.
-func (c *Checker) performChecks(
-	backendsToCheck []string,
-	checkTimeout time.Duration,
-	checkPath string,
-	onUpdate func([]string)) {
-	if len(backendsToCheck) == 0 {
-		slog.Debug("HealthChecker: no backends to check in this round.")
-		if onUpdate != nil {
-			onUpdate([]string{})
+// round параметры цикла проверок, зафиксированные при Start
+type round struct {
+	backends []backend.Backend
+	interval time.Duration
+	timeout  time.Duration
+	jitter   time.Duration
+	checks   map[string]Check
+	sem      chan struct{} // Ограничение одновременных проверок; nil - без ограничения
+	onUpdate func([]backend.Backend)
+}
+
+// schedule проверяет бэкенд раз в interval плюс случайное смещение до jitter.
+// Первая проверка выполняется через случайное время до jitter после старта.
+func (c *Checker) schedule(ctx context.Context, r *round, b backend.Backend) {
+	timer := time.NewTimer(randomDelay(r.jitter))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
 		}
-		return
+
+		res, ok := c.probe(ctx, r, b)
+		if !ok {
+			return // Цикл остановлен: результат отмененной проверки не учитывается
+		}
+		c.record(r, b, res)
+		timer.Reset(r.interval + randomDelay(r.jitter))
 	}
+}
 
-	slog.Debug(
-		"HealthChecker: starting a round of health checks",
-		slog.Any("backends", backendsToCheck),
-	)
+// probe выполняет одну проверку бэкенда с учетом ограничения одновременных проверок.
+// Возвращает false, если цикл проверок остановлен.
+func (c *Checker) probe(ctx context.Context, r *round, b backend.Backend) (probeResult, bool) {
+	if r.sem != nil {
+		select {
+		case r.sem <- struct{}{}:
+			defer func() { <-r.sem }()
+		case <-ctx.Done():
+			return probeResult{}, false
+		}
+	}
 
-	liveBackends := make([]string, 0, len(backendsToCheck))
-	var wgChecks sync.WaitGroup
-	muLive := &sync.Mutex{}
+	probeCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
-	// Создаем HTTP клиент для этой сессии проверок
-	// DisableKeepAlives: true - не держать лишние соединения к потенциально больным серверам.
-	client := http.Client{
-		Timeout: checkTimeout,
-		Transport: &http.Transport{
-			DisableKeepAlives: true,
-		},
+	start := time.Now()
+	conn := c.connFor(b.URL)
+	prober, err := NewProber(r.checks[b.URL], conn.client, conn.tls)
+	if err == nil {
+		err = prober.Probe(probeCtx, b)
+	}
+	if ctx.Err() != nil {
+		slog.Debug("HealthChecker: request cancelled for backend", slog.String("backend", b.URL))
+		return probeResult{}, false
 	}
+	if err != nil {
+		slog.Debug(
+			"HealthChecker: check failed for backend",
+			slog.String("backend", b.URL),
+			slog.String("error", err.Error()))
+	}
+	return probeResult{err: err, latency: time.Since(start), time: start}, true
+}
 
-	for _, backendAddr := range backendsToCheck {
-		wgChecks.Add(1)
-		go func(addr string) {
-			defer wgChecks.Done()
-			urlToCheck := strings.TrimSuffix(addr, "/")
-			if !strings.HasPrefix(urlToCheck, "http://") && !strings.HasPrefix(urlToCheck, "https://") {
-				urlToCheck = "http://" + urlToCheck
-			}
+func randomDelay(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return rand.N(jitter)
+}
 
-			pathPart := strings.TrimPrefix(checkPath, "/")
-			if pathPart != "" {
-				urlToCheck = urlToCheck + "/" + pathPart
-			}
+// checksFor возвращает проверку для каждого бэкенда с учетом переопределений. Вызывается под c.mu.
+func (c *Checker) checksFor(backends []backend.Backend) map[string]Check {
+	checks := make(map[string]Check, len(backends))
+	for _, b := range backends {
+		if check, ok := c.overrides[b.URL]; ok {
+			checks[b.URL] = check
+		} else {
+			checks[b.URL] = c.check
+		}
+	}
+	return checks
+}
 
-			req, err := http.NewRequestWithContext(
-				c.activeCtx,
-				http.MethodGet,
-				urlToCheck,
-				nil,
-			)
+// connFor возвращает соединения проверок бэкенда
+func (c *Checker) connFor(url string) *probeConn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if conn, ok := c.tlsConns[url]; ok {
+		return conn
+	}
+	return c.conn
+}
 
-			if err != nil {
-				slog.Warn(
-					"HealthChecker: failed to create request",
-					slog.String("backend", addr),
-					slog.String("url", urlToCheck),
-					slog.String("error", err.Error()))
-				return
-			}
+// closeConns закрывает простаивающие соединения проверок. Вызывается под c.mu.
+func (c *Checker) closeConns() {
+	c.conn.close()
+	for _, conn := range c.tlsConns {
+		conn.close()
+	}
+}
 
-			resp, err := client.Do(req)
-			if err != nil {
-				if c.activeCtx.Err() != nil {
-					slog.Debug(
-						"HealthChecker: request cancelled for backend",
-						slog.String("backend", addr),
-						slog.String("url", urlToCheck))
-				} else {
-					slog.Warn(
-						"HealthChecker: check failed for backend",
-						slog.String("backend", addr),
-						slog.String("url", urlToCheck),
-						slog.String("error", err.Error()))
-				}
-				return
-			}
+// probeConn HTTP-клиент проверок и параметры TLS для gRPC-проверок
+type probeConn struct {
+	transport *http.Transport
+	client    *http.Client
+	tls       *tls.Config
+}
 
-			defer resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				muLive.Lock()
-				liveBackends = append(liveBackends, addr)
-				muLive.Unlock()
-			} else {
-				slog.Warn(
-					"HealthChecker: backend unhealthy",
-					slog.String("backend", addr),
-					slog.String("url", urlToCheck),
-					slog.Int("status_code", resp.StatusCode))
-			}
-		}(backendAddr)
+func newProbeConn(tlsConfig *tls.Config) *probeConn {
+	// Соединения переиспользуются между проверками; по одному простаивающему на бэкенд
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: 1,
+		IdleConnTimeout:     time.Minute,
+		TLSClientConfig:     tlsConfig,
+	}
+	return &probeConn{
+		transport: transport,
+		client:    &http.Client{Transport: transport},
+		tls:       tlsConfig,
+	}
+}
+
+func (p *probeConn) close() {
+	p.transport.CloseIdleConnections()
+}
+
+// record применяет результат проверки к состоянию бэкенда с учетом порогов,
+// рассылает событие перехода и уведомляет об изменении списка живых
+func (c *Checker) record(r *round, b backend.Backend, res probeResult) {
+	// Уведомления сериализуются, чтобы списки живых приходили в OnUpdate по порядку
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+
+	c.mu.Lock()
+	s := c.states[b.URL]
+	if s == nil {
+		s = &backendState{}
+		c.states[b.URL] = s
+	}
+	ev, changed := c.observe(s, res)
+	if changed {
+		ev.Backend = b.URL
+		s.history = append(s.history, ev)
+		if len(s.history) > historySize {
+			s.history = s.history[len(s.history)-historySize:]
+		}
+	}
+	subscribers, probeSubscribers := c.subscribers, c.probeSubscribers
+	c.mu.Unlock()
+
+	probe := Probe{Backend: b.URL, Reason: reason(res.err), Latency: res.latency}
+	for _, fn := range probeSubscribers {
+		fn(probe)
+	}
+	if changed {
+		logEvent(ev)
+		for _, fn := range subscribers {
+			fn(ev)
+		}
+	}
+	c.notifyLocked(r)
+}
+
+// notify вызывает onUpdate, если список живых изменился
+func (c *Checker) notify(r *round) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.notifyLocked(r)
+}
+
+// notifyLocked вызывается под c.notifyMu. Пока не проверены все бэкенды,
+// список не передается, чтобы балансировщик не получил неполный список при старте.
+func (c *Checker) notifyLocked(r *round) {
+	c.mu.Lock()
+	// Бэкенды передаются целиком (с весом), чтобы параметры не терялись по пути в балансировщик
+	live := make([]backend.Backend, 0, len(r.backends))
+	for _, b := range r.backends {
+		s, ok := c.states[b.URL]
+		if !ok || s.state == StateUnknown {
+			c.mu.Unlock()
+			return
+		}
+		if s.state == StateHealthy {
+			live = append(live, b)
+		}
+	}
+
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].URL < live[j].URL
+	})
+	changed := c.lastLive == nil || !slices.Equal(live, c.lastLive)
+	if changed {
+		c.lastLive = live
 	}
+	c.mu.Unlock()
 
-	wgChecks.Wait()
-	sort.Strings(liveBackends)
+	if !changed {
+		return
+	}
 	slog.Info(
-		"HealthChecker: health check round completed",
-		slog.Any("live_backends", liveBackends),
-		slog.Int("total_checked", len(backendsToCheck)))
-	if onUpdate != nil {
-		onUpdate(liveBackends)
+		"HealthChecker: live backends changed",
+		slog.Any("live_backends", backend.URLs(live)),
+		slog.Int("total_checked", len(r.backends)))
+	if r.onUpdate != nil {
+		r.onUpdate(live)
+	}
+}
+
+// observe учитывает результат проверки. Первая проверка нового бэкенда определяет его состояние сразу,
+// дальше состояние меняется после healthyThreshold/unhealthyThreshold проверок подряд.
+// Возвращает событие, если состояние бэкенда сменилось. Вызывается под c.mu.
+func (c *Checker) observe(s *backendState, res probeResult) (Event, bool) {
+	s.last = res
+
+	next := s.state
+	consecutive := 0
+	if res.err == nil {
+		s.successes++
+		s.failures = 0
+		consecutive = s.successes
+		if s.state == StateUnknown || s.successes >= c.healthyThreshold {
+			next = StateHealthy
+		}
+	} else {
+		s.failures++
+		s.successes = 0
+		consecutive = s.failures
+		if s.state == StateUnknown || s.failures >= c.unhealthyThreshold {
+			next = StateUnhealthy
+		}
+	}
+	if next == s.state {
+		return Event{}, false
+	}
+
+	ev := Event{
+		From:        s.state,
+		To:          next,
+		Reason:      reason(res.err),
+		Consecutive: consecutive,
+		Latency:     res.latency,
+		Time:        res.time,
+	}
+	if res.err != nil {
+		ev.Error = res.err.Error()
+	}
+	s.state = next
+	s.since = res.time
+	return ev, true
+}
+
+// logEvent пишет переход в лог, например "backend down after 3 timeout"
+func logEvent(ev Event) {
+	attrs := []any{
+		slog.String("backend", ev.Backend),
+		slog.String("from", ev.From.String()),
+		slog.String("to", ev.To.String()),
+		slog.String("reason", ev.Reason),
+		slog.Int("consecutive", ev.Consecutive),
+		slog.Duration("latency", ev.Latency),
+	}
+	if ev.To == StateUnhealthy {
+		slog.Warn("HealthChecker: backend down", append(attrs, slog.String("error", ev.Error))...)
+		return
 	}
+	slog.Info("HealthChecker: backend up", attrs...)
 }
 
 /*
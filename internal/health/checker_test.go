@@ -0,0 +1,209 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"load-balancer/internal/backend"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkOnce проверяет все бэкенды один раз, последовательно
+func checkOnce(c *Checker, backends []backend.Backend) {
+	r := &round{
+		backends: backends,
+		timeout:  time.Second,
+		checks:   c.checksFor(backends),
+		onUpdate: c.OnUpdate,
+	}
+	for _, b := range backends {
+		if res, ok := c.probe(context.Background(), r, b); ok {
+			c.record(r, b, res)
+		}
+	}
+}
+
+func TestThresholdsAndChangeNotification(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var updates [][]backend.Backend
+	backends := []backend.Backend{{URL: srv.URL}}
+	check := Check{Path: "/health"}
+	c := NewChecker(backends, 0, 0, check, func(live []backend.Backend) {
+		updates = append(updates, live)
+	})
+	c.SetThresholds(2, 2)
+
+	round := func() { checkOnce(c, backends) }
+
+	round() // Первая проверка определяет состояние сразу
+	round() // Без изменений - без уведомления
+	if len(updates) != 1 || len(updates[0]) != 1 {
+		t.Fatalf("updates = %v, want one update with live backend", updates)
+	}
+
+	healthy.Store(false)
+	round()
+	if len(updates) != 1 {
+		t.Fatal("backend dropped after a single failure")
+	}
+	round()
+	if len(updates) != 2 || len(updates[1]) != 0 {
+		t.Fatalf("updates = %v, want backend dropped after 2 failures", updates)
+	}
+
+	healthy.Store(true)
+	round()
+	round()
+	if len(updates) != 3 || len(updates[2]) != 1 {
+		t.Fatalf("updates = %v, want backend back after 2 successes", updates)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "lb-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+	srv.TLS = &tls.Config{ClientCAs: clientCAs, ClientAuth: tls.RequireAndVerifyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	var live []backend.Backend
+	backends := []backend.Backend{{URL: srv.URL}}
+	c := NewChecker(backends, 0, 0, Check{Path: "/health"}, func(l []backend.Backend) { live = l })
+
+	checkOnce(c, backends)
+	if len(live) != 0 {
+		t.Fatal("backend is healthy without client certificate")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	c.SetTLS(nil, map[string]*tls.Config{srv.URL: {
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}})
+	checkOnce(c, backends)
+	if len(live) != 1 {
+		t.Fatalf("backend is not healthy with client certificate: %+v", c.Statuses())
+	}
+}
+
+func TestEventsAndHistory(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	backends := []backend.Backend{{URL: srv.URL}}
+	c := NewChecker(backends, 0, 0, Check{Path: "/health"}, nil)
+	c.SetThresholds(1, 3)
+
+	var events []Event
+	c.Subscribe(func(ev Event) { events = append(events, ev) })
+	round := func() { checkOnce(c, backends) }
+
+	healthy.Store(true)
+	round()
+	healthy.Store(false)
+	for i := 0; i < 3; i++ {
+		round()
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want 2 transitions", events)
+	}
+	if ev := events[0]; ev.From != StateUnknown || ev.To != StateHealthy || ev.Reason != ReasonOK {
+		t.Errorf("first event = %+v", ev)
+	}
+	down := events[1]
+	if down.From != StateHealthy || down.To != StateUnhealthy || down.Reason != ReasonStatusCode || down.Consecutive != 3 {
+		t.Errorf("down event = %+v", down)
+	}
+
+	st, ok := c.Status(srv.URL)
+	if !ok || st.State != StateUnhealthy || !st.Since.Equal(down.Time) || len(st.History) != 2 {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestScheduling(t *testing.T) {
+	var active, peak, probes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		probes.Add(1)
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	// Разные URL одного сервера - разные бэкенды
+	backends := []backend.Backend{{URL: srv.URL + "/a"}, {URL: srv.URL + "/b"}, {URL: srv.URL + "/c"}}
+	updates := make(chan []backend.Backend, 10)
+	c := NewChecker(backends, time.Hour, time.Second, Check{}, func(live []backend.Backend) {
+		updates <- live
+	})
+	c.SetScheduling(20*time.Millisecond, 1)
+
+	c.Start(context.Background())
+	defer c.Stop()
+
+	// Список живых передается один раз, когда проверены все бэкенды
+	select {
+	case live := <-updates:
+		if len(live) != len(backends) {
+			t.Fatalf("first update = %v, want all backends", backend.URLs(live))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update")
+	}
+	if got := probes.Load(); got != int32(len(backends)) {
+		t.Errorf("probes = %d, want %d", got, len(backends))
+	}
+	if got := peak.Load(); got != 1 {
+		t.Errorf("peak concurrent probes = %d, want 1", got)
+	}
+}
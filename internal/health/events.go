@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+// historySize сколько последних переходов хранится для каждого бэкенда
+const historySize = 20
+
+// State состояние бэкенда по результатам активных проверок
+type State int
+
+const (
+	StateUnknown State = iota // Бэкенд еще не проверялся
+	StateHealthy
+	StateUnhealthy
+)
+
+func (s State) String() string {
+	switch s {
+	case StateHealthy:
+		return "healthy"
+	case StateUnhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// Причины результата проверки
+const (
+	ReasonOK           = "ok"
+	ReasonTimeout      = "timeout"
+	ReasonConnectError = "connect error"
+	ReasonStatusCode   = "status code"
+	ReasonError        = "error"
+)
+
+// Event переход бэкенда в другое состояние
+type Event struct {
+	Backend     string
+	From, To    State
+	Reason      string        // Причина последней проверки: ok, timeout, connect error, status code, error
+	Error       string        // Текст ошибки последней проверки
+	Consecutive int           // Проверок подряд с таким результатом, приведших к переходу
+	Latency     time.Duration // Длительность последней проверки
+	Time        time.Time
+}
+
+// Status текущее состояние бэкенда и история его переходов
+type Status struct {
+	Backend     string
+	State       State
+	Since       time.Time // Время последнего перехода
+	Reason      string    // Причина последней проверки
+	Error       string
+	Consecutive int // Проверок подряд с результатом последней проверки
+	Latency     time.Duration
+	History     []Event // Последние переходы, от старых к новым
+}
+
+// StatusError ответ бэкенда с неожиданным кодом
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected status code " + strconv.Itoa(e.Code)
+}
+
+// Probe итог одной проверки бэкенда
+type Probe struct {
+	Backend string
+	Reason  string // ok, timeout, connect error, status code, error
+	Latency time.Duration
+}
+
+// probeResult результат одной проверки
+type probeResult struct {
+	err     error
+	latency time.Duration
+	time    time.Time
+}
+
+// reason классифицирует результат проверки
+func reason(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case err == nil:
+		return ReasonOK
+	case errors.As(err, &statusErr):
+		return ReasonStatusCode
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ReasonTimeout
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return ReasonConnectError
+	default:
+		return ReasonError
+	}
+}
+
+// Subscribe добавляет обработчик переходов бэкендов. Обработчики вызываются
+// последовательно из цикла проверок и не должны блокироваться.
+func (c *Checker) Subscribe(fn func(Event)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers = append(c.subscribers, fn)
+}
+
+// SubscribeProbes добавляет обработчик каждой проверки (например, для метрик).
+// Обработчики вызываются из цикла проверок и не должны блокироваться.
+func (c *Checker) SubscribeProbes(fn func(Probe)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probeSubscribers = append(c.probeSubscribers, fn)
+}
+
+// Statuses возвращает состояние всех проверяемых бэкендов
+func (c *Checker) Statuses() []Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(c.states))
+	for url, s := range c.states {
+		statuses = append(statuses, s.status(url))
+	}
+	return statuses
+}
+
+// Status возвращает состояние бэкенда
+func (c *Checker) Status(backend string) (Status, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, ok := c.states[backend]
+	if !ok {
+		return Status{Backend: backend}, false
+	}
+	return s.status(backend), true
+}
+
+func (s *backendState) status(url string) Status {
+	consecutive := s.successes
+	if s.last.err != nil {
+		consecutive = s.failures
+	}
+	st := Status{
+		Backend:     url,
+		State:       s.state,
+		Since:       s.since,
+		Reason:      reason(s.last.err),
+		Consecutive: consecutive,
+		Latency:     s.last.latency,
+		History:     append([]Event(nil), s.history...),
+	}
+	if s.last.err != nil {
+		st.Error = s.last.err.Error()
+	}
+	return st
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"load-balancer/internal/backend"
+)
+
+// GRPCProber проверка по стандартному протоколу grpc.health.v1.Health/Check.
+// Для бэкендов со схемой https используется TLS.
+type GRPCProber struct {
+	Port    string      // Порт проверки; пусто - порт бэкенда
+	Service string      // Имя сервиса; пусто - сервер целиком
+	TLS     *tls.Config // Для бэкендов https; nil - параметры по умолчанию
+}
+
+func (p *GRPCProber) Probe(ctx context.Context, b backend.Backend) error {
+	addr, err := hostPort(b, p.Port)
+	if err != nil {
+		return fmt.Errorf("invalid backend address: %w", err)
+	}
+
+	creds := insecure.NewCredentials()
+	if u, _ := baseURL(b); u.Scheme == "https" {
+		cfg := &tls.Config{}
+		if p.TLS != nil {
+			cfg = p.TLS
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.Service})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return fmt.Errorf("grpc health service not implemented: %w", err)
+		}
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc health status %s", resp.GetStatus())
+	}
+	return nil
+}
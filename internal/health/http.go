@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"load-balancer/internal/backend"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxBodyBytes сколько байт тела ответа читается для проверки содержимого
+const maxBodyBytes = 64 << 10
+
+// Check параметры проверки бэкенда
+type Check struct {
+	Type string // http (по умолчанию) | tcp | grpc
+	Port string // Порт проверки; пусто - порт бэкенда
+
+	// HTTP
+	Path         string            // Путь, например "/health"
+	Method       string            // Пусто - GET
+	Headers      map[string]string // Заголовки запроса; "Host" задает виртуальный хост
+	Statuses     []StatusRange     // Ожидаемые коды ответа; пусто - только 200
+	BodyContains string            // Подстрока, которая должна быть в теле ответа
+	BodyRegex    *regexp.Regexp    // Регулярное выражение для тела ответа
+
+	// TCP
+	Send   string // Данные, отправляемые после соединения
+	Expect string // Подстрока, которую бэкенд должен прислать в ответ
+
+	// gRPC
+	Service string // Имя сервиса для grpc.health.v1; пусто - сервер целиком
+}
+
+// HTTPProber проверка HTTP-запросом
+type HTTPProber struct {
+	Client *http.Client
+	Check  Check
+}
+
+// StatusRange диапазон кодов ответа, включительно
+type StatusRange struct {
+	Min, Max int
+}
+
+// ParseStatusRanges разбирает коды ответа: "200", "200-299" или "2xx"
+func ParseStatusRanges(specs []string) ([]StatusRange, error) {
+	ranges := make([]StatusRange, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+
+		var r StatusRange
+		var err error
+		switch lo, hi, isRange := strings.Cut(spec, "-"); {
+		case isRange:
+			if r.Min, err = strconv.Atoi(lo); err == nil {
+				r.Max, err = strconv.Atoi(hi)
+			}
+		case len(spec) == 3 && strings.EqualFold(spec[1:], "xx"):
+			var class int
+			class, err = strconv.Atoi(spec[:1])
+			r = StatusRange{Min: class * 100, Max: class*100 + 99}
+		default:
+			r.Min, err = strconv.Atoi(spec)
+			r.Max = r.Min
+		}
+
+		if err != nil || r.Min < 100 || r.Max > 599 || r.Min > r.Max {
+			return nil, fmt.Errorf("invalid status code range %q", spec)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (c Check) statusOK(code int) bool {
+	if len(c.Statuses) == 0 {
+		return code == http.StatusOK
+	}
+	for _, r := range c.Statuses {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// url строит адрес проверки: схема и хост бэкенда, порт проверки и путь
+func (c Check) url(b backend.Backend) (string, error) {
+	u, err := baseURL(b)
+	if err != nil {
+		return "", err
+	}
+	if c.Port != "" {
+		u.Host = net.JoinHostPort(u.Hostname(), c.Port)
+	}
+	if path := strings.TrimPrefix(c.Path, "/"); path != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + path
+	}
+	return u.String(), nil
+}
+
+func (p *HTTPProber) Probe(ctx context.Context, b backend.Backend) error {
+	check := p.Check
+	target, err := check.url(b)
+	if err != nil {
+		return fmt.Errorf("invalid health check URL: %w", err)
+	}
+
+	method := check.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range check.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !check.statusOK(resp.StatusCode) {
+		return &StatusError{Code: resp.StatusCode}
+	}
+	if check.BodyContains == "" && check.BodyRegex == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+	if check.BodyContains != "" && !strings.Contains(string(body), check.BodyContains) {
+		return errors.New("body does not contain expected substring")
+	}
+	if check.BodyRegex != nil && !check.BodyRegex.Match(body) {
+		return errors.New("body does not match expected pattern")
+	}
+	return nil
+}
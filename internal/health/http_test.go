@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"load-balancer/internal/backend"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := ParseStatusRanges([]string{"204", "300-302", "2xx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := Check{Statuses: ranges}
+	for code, want := range map[int]bool{200: true, 204: true, 301: true, 303: false, 404: false} {
+		if got := check.statusOK(code); got != want {
+			t.Errorf("statusOK(%d) = %v, want %v", code, got, want)
+		}
+	}
+
+	for _, bad := range []string{"abc", "302-300", "6xx", "99"} {
+		if _, err := ParseStatusRanges([]string{bad}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/status" && r.Method == http.MethodGet:
+		case r.URL.Path == "/head" && r.Method == http.MethodHead:
+		case r.URL.Path == "/status" || r.URL.Path == "/head":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Host != "app.internal" || r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"ok","version":"1.2"}`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+	// Бэкенд обслуживает трафик на другом порту, проверка идет на порт сервера
+	b := backend.Backend{URL: "http://127.0.0.1:1"}
+
+	base := Check{
+		Path:     "/status",
+		Port:     port,
+		Headers:  map[string]string{"Host": "app.internal", "Authorization": "Bearer t"},
+		Statuses: []StatusRange{{Min: 200, Max: 299}},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Check)
+		ok     bool
+	}{
+		{"status range", func(*Check) {}, true},
+		{"exact 200 by default", func(c *Check) { c.Statuses = nil }, false},
+		{"missing header", func(c *Check) { c.Headers = nil }, false},
+		{"body substring", func(c *Check) { c.BodyContains = `"status":"ok"` }, true},
+		{"body substring mismatch", func(c *Check) { c.BodyContains = "degraded" }, false},
+		{"body regex", func(c *Check) { c.BodyRegex = regexp.MustCompile(`"version":"1\.\d+"`) }, true},
+		{"wrong path", func(c *Check) { c.Path = "/health" }, false},
+		{"head method", func(c *Check) { c.Path, c.Method = "/head", http.MethodHead }, true},
+		{"get on head-only endpoint", func(c *Check) { c.Path = "/head" }, false},
+		{"wrong method", func(c *Check) { c.Method = http.MethodPost }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := base
+			tt.modify(&check)
+			p := &HTTPProber{Client: srv.Client(), Check: check}
+			err := p.Probe(context.Background(), b)
+			if (err == nil) != tt.ok {
+				t.Errorf("probe error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"load-balancer/internal/backend"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Типы проверок
+const (
+	TypeHTTP = "http"
+	TypeTCP  = "tcp"
+	TypeGRPC = "grpc"
+)
+
+// Prober выполняет одну проверку бэкенда. nil - бэкенд здоров, иначе ошибка с причиной.
+// Таймаут проверки задается через ctx.
+type Prober interface {
+	Probe(ctx context.Context, b backend.Backend) error
+}
+
+// NewProber создает проверку по check.Type. client используется HTTP-проверками,
+// tlsConfig - gRPC-проверками бэкендов https (nil - параметры по умолчанию).
+func NewProber(check Check, client *http.Client, tlsConfig *tls.Config) (Prober, error) {
+	switch check.Type {
+	case "", TypeHTTP:
+		return &HTTPProber{Client: client, Check: check}, nil
+	case TypeTCP:
+		return &TCPProber{Port: check.Port, Send: check.Send, Expect: check.Expect}, nil
+	case TypeGRPC:
+		return &GRPCProber{Port: check.Port, Service: check.Service, TLS: tlsConfig}, nil
+	default:
+		return nil, fmt.Errorf("unknown health check type %q", check.Type)
+	}
+}
+
+// baseURL адрес бэкенда со схемой; бэкенды без схемы считаются http
+func baseURL(b backend.Backend) (*url.URL, error) {
+	addr := strings.TrimSuffix(b.URL, "/")
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	return url.Parse(addr)
+}
+
+// hostPort адрес для соединения с бэкендом. port заменяет порт бэкенда, если задан.
+func hostPort(b backend.Backend, port string) (string, error) {
+	u, err := baseURL(b)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case port != "":
+	case u.Port() != "":
+		port = u.Port()
+	case u.Scheme == "https":
+		port = "443"
+	default:
+		port = "80"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
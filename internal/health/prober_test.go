@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"load-balancer/internal/backend"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPProber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 16)
+			n, _ := conn.Read(buf)
+			if string(buf[:n]) == "PING\r\n" {
+				_, _ = conn.Write([]byte("+PONG\r\n"))
+			}
+			conn.Close()
+		}
+	}()
+
+	b := backend.Backend{URL: "http://" + ln.Addr().String()}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := (&TCPProber{}).Probe(ctx, b); err != nil {
+		t.Errorf("connect: %v", err)
+	}
+	if err := (&TCPProber{Send: "PING\r\n", Expect: "PONG"}).Probe(ctx, b); err != nil {
+		t.Errorf("send/expect: %v", err)
+	}
+	if err := (&TCPProber{Send: "HELLO\r\n", Expect: "PONG"}).Probe(ctx, b); err == nil {
+		t.Error("expected failure on unexpected response")
+	}
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+	if err := (&TCPProber{Port: port}).Probe(ctx, b); err == nil {
+		t.Error("expected connect error on closed port")
+	}
+}
+
+func TestGRPCProber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := grpchealth.NewServer()
+	hs.SetServingStatus("app", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("batch", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Stop()
+
+	b := backend.Backend{URL: ln.Addr().String()}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := (&GRPCProber{Service: "app"}).Probe(ctx, b); err != nil {
+		t.Errorf("serving service: %v", err)
+	}
+	if err := (&GRPCProber{Service: "batch"}).Probe(ctx, b); err == nil {
+		t.Error("expected failure for NOT_SERVING service")
+	}
+	if err := (&GRPCProber{Service: "unknown"}).Probe(ctx, b); err == nil {
+		t.Error("expected failure for unknown service")
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"load-balancer/internal/backend"
+	"net"
+)
+
+// maxExpectBytes сколько байт ответа читается в поисках Expect
+const maxExpectBytes = 4 << 10
+
+// TCPProber проверка установкой TCP-соединения с необязательным обменом данными
+type TCPProber struct {
+	Port   string // Порт проверки; пусто - порт бэкенда
+	Send   string // Данные, отправляемые после соединения
+	Expect string // Подстрока, которую бэкенд должен прислать в ответ
+}
+
+func (p *TCPProber) Probe(ctx context.Context, b backend.Backend) error {
+	addr, err := hostPort(b, p.Port)
+	if err != nil {
+		return fmt.Errorf("invalid backend address: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if p.Send != "" {
+		if _, err := conn.Write([]byte(p.Send)); err != nil {
+			return fmt.Errorf("send failed: %w", err)
+		}
+	}
+	if p.Expect == "" {
+		return nil
+	}
+
+	buf := make([]byte, 0, 512)
+	chunk := make([]byte, 512)
+	for len(buf) < maxExpectBytes {
+		n, err := conn.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if bytes.Contains(buf, []byte(p.Expect)) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("expected response not received: %w", err)
+		}
+	}
+	return errors.New("expected response not received")
+}
@@ -0,0 +1,189 @@
+/*
+Пакет metrics реализует метрики Prometheus:
+- Запросы к бэкендам по классу статуса и методу, задержки и активные запросы
+- Состояние бэкендов и длительность проверок здоровья
+- Решения rate limiter по правилам
+- Перезагрузки конфигурации
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const namespace = "lb"
+
+// Metrics метрики балансировщика в собственном реестре
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	inFlight      *prometheus.GaugeVec
+	health        *prometheus.GaugeVec
+	probeDuration *prometheus.HistogramVec
+	rateLimit     *prometheus.CounterVec
+	configReloads *prometheus.CounterVec
+
+	mu       sync.Mutex
+	backends map[string]bool // Бэкенды, по которым есть серии
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		backends: make(map[string]bool),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Requests proxied to backends, including retries, by status class (1xx-5xx, error) and method.",
+		}, []string{"backend", "code", "method"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Time until backend response headers are received.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "upstream_in_flight_requests",
+			Help:      "Requests currently proxied to a backend.",
+		}, []string{"backend"}),
+		health: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "backend_healthy",
+			Help:      "Active health check state of a backend: 1 healthy, 0 unhealthy.",
+		}, []string{"backend"}),
+		probeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "health_probe_duration_seconds",
+			Help:      "Health probe duration by result (ok, timeout, connect error, status code, error).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "result"}),
+		rateLimit: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_decisions_total",
+			Help:      "Rate limiter decisions by rule (default or client override) and decision (allow, deny).",
+		}, []string{"rule", "decision"}),
+		configReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "config_reloads_total",
+			Help:      "Config reloads by result (success, failure).",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests, m.duration, m.inFlight, m.health, m.probeDuration, m.rateLimit, m.configReloads,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler отдает метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Registry реестр метрик (для регистрации дополнительных коллекторов)
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RequestStarted учитывает активный запрос к бэкенду. Возвращает функцию завершения запроса.
+func (m *Metrics) RequestStarted(backend string) func() {
+	m.track(backend)
+	g := m.inFlight.WithLabelValues(backend)
+	g.Inc()
+	return g.Dec
+}
+
+// ObserveRequest учитывает завершенную попытку проксирования.
+// status 0 вместе с failed - ошибка без ответа бэкенда.
+func (m *Metrics) ObserveRequest(backend, method string, status int, failed bool, rtt time.Duration) {
+	m.track(backend)
+	m.requests.WithLabelValues(backend, codeClass(status, failed), method).Inc()
+	m.duration.WithLabelValues(backend).Observe(rtt.Seconds())
+}
+
+// SetHealthy задает состояние бэкенда по активной проверке
+func (m *Metrics) SetHealthy(backend string, healthy bool) {
+	m.track(backend)
+	v := 0.0
+	if healthy {
+		v = 1
+	}
+	m.health.WithLabelValues(backend).Set(v)
+}
+
+// ObserveProbe учитывает проверку здоровья
+func (m *Metrics) ObserveProbe(backend, result string, latency time.Duration) {
+	m.track(backend)
+	m.probeDuration.WithLabelValues(backend, result).Observe(latency.Seconds())
+}
+
+// ObserveRateLimit учитывает решение rate limiter
+func (m *Metrics) ObserveRateLimit(rule string, allowed bool) {
+	decision := "deny"
+	if allowed {
+		decision = "allow"
+	}
+	m.rateLimit.WithLabelValues(rule, decision).Inc()
+}
+
+// ObserveConfigReload учитывает перезагрузку конфигурации
+func (m *Metrics) ObserveConfigReload(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.configReloads.WithLabelValues(result).Inc()
+}
+
+// RetainBackends удаляет серии бэкендов, которых нет в backends (убраны из конфигурации)
+func (m *Metrics) RetainBackends(backends []string) {
+	keep := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		keep[b] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for b := range m.backends {
+		if keep[b] {
+			continue
+		}
+		labels := prometheus.Labels{"backend": b}
+		m.requests.DeletePartialMatch(labels)
+		m.duration.DeletePartialMatch(labels)
+		m.inFlight.DeletePartialMatch(labels)
+		m.health.DeletePartialMatch(labels)
+		m.probeDuration.DeletePartialMatch(labels)
+		delete(m.backends, b)
+	}
+}
+
+func (m *Metrics) track(backend string) {
+	m.mu.Lock()
+	m.backends[backend] = true
+	m.mu.Unlock()
+}
+
+// codeClass возвращает класс статуса ответа: 2xx, 5xx или error, если ответа нет
+func codeClass(status int, failed bool) string {
+	if status == 0 {
+		if failed {
+			return "error"
+		}
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
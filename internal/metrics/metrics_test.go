@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+
+	done := m.RequestStarted("http://a")
+	m.ObserveRequest("http://a", "GET", 0, true, time.Millisecond)
+	m.ObserveRequest("http://b", "POST", 201, false, time.Millisecond)
+	m.SetHealthy("http://a", false)
+	m.SetHealthy("http://b", true)
+	m.ObserveProbe("http://a", "timeout", time.Second)
+	m.ObserveRateLimit("default", true)
+	m.ObserveRateLimit("client:vip", false)
+	m.ObserveConfigReload(nil)
+	m.ObserveConfigReload(errors.New("bad yaml"))
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`lb_upstream_in_flight_requests{backend="http://a"} 1`,
+		`lb_upstream_requests_total{backend="http://a",code="error",method="GET"} 1`,
+		`lb_upstream_requests_total{backend="http://b",code="2xx",method="POST"} 1`,
+		`lb_upstream_request_duration_seconds_count{backend="http://b"} 1`,
+		`lb_backend_healthy{backend="http://a"} 0`,
+		`lb_backend_healthy{backend="http://b"} 1`,
+		`lb_health_probe_duration_seconds_count{backend="http://a",result="timeout"} 1`,
+		`lb_ratelimit_decisions_total{decision="allow",rule="default"} 1`,
+		`lb_ratelimit_decisions_total{decision="deny",rule="client:vip"} 1`,
+		`lb_config_reloads_total{result="success"} 1`,
+		`lb_config_reloads_total{result="failure"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	done()
+	if out := scrape(t, m); !strings.Contains(out, `lb_upstream_in_flight_requests{backend="http://a"} 0`) {
+		t.Error("in-flight gauge not decremented")
+	}
+}
+
+func TestRetainBackends(t *testing.T) {
+	m := New()
+	m.SetHealthy("http://a", true)
+	m.SetHealthy("http://b", true)
+	m.ObserveProbe("http://a", "ok", time.Millisecond)
+
+	m.RetainBackends([]string{"http://b"})
+
+	out := scrape(t, m)
+	if strings.Contains(out, `backend="http://a"`) {
+		t.Error("series of removed backend are still exported")
+	}
+	if !strings.Contains(out, `lb_backend_healthy{backend="http://b"} 1`) {
+		t.Error("series of kept backend were removed")
+	}
+}
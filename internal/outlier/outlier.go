@@ -0,0 +1,224 @@
+/*
+Пакет outlier реализует пассивную проверку здоровья по реальному трафику:
+- Учет ошибок соединения подряд и доли ответов 5xx за интервал
+- Исключение бэкенда на время, растущее экспоненциально с каждым исключением
+- Ограничение доли одновременно исключенных бэкендов
+*/
+
+package outlier
+
+import (
+	"load-balancer/internal/backend"
+	"load-balancer/internal/balancer"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	ConsecutiveErrors  int           // Ошибок соединения/таймаутов подряд для исключения; 0 - не учитывать
+	ErrorRatePercent   int           // Доля ответов 5xx за интервал для исключения; 0 - не учитывать
+	MinRequests        int           // Минимум запросов за интервал, чтобы учитывать долю 5xx
+	Interval           time.Duration // Интервал подсчета доли 5xx
+	BaseEjectionTime   time.Duration // Время первого исключения, дальше удваивается
+	MaxEjectionTime    time.Duration // Предел времени исключения
+	MaxEjectionPercent int           // Максимум одновременно исключенных бэкендов, % от всех
+}
+
+// Detector исключает бэкенды по итогам проксированных запросов. Реализует balancer.Filter.
+// Активные проверки health checker'а и решения Detector объединяет AtomicBalancer:
+// кандидаты - живые бэкенды, которые не исключены.
+type Detector struct {
+	mu       sync.Mutex
+	cfg      Config
+	total    int // Бэкендов в конфигурации, для MaxEjectionPercent
+	hosts    map[string]*host
+	onChange func() // Вызывается вне блокировки при изменении списка исключенных
+}
+
+type host struct {
+	consecutive int       // Ошибок соединения подряд
+	windowStart time.Time // Начало текущего интервала
+	requests    int
+	errors5xx   int
+	ejections   int       // Исключений подряд; уменьшается за каждый интервал без исключения
+	ejectedAt   time.Time // Нулевое время - не исключен
+}
+
+var _ balancer.Filter = (*Detector)(nil)
+
+// New создает детектор. onChange вызывается при изменении списка исключенных
+// бэкендов (обычно AtomicBalancer.Refresh).
+func New(cfg Config, onChange func()) *Detector {
+	if onChange == nil {
+		onChange = func() {}
+	}
+	return &Detector{
+		cfg:      normalize(cfg),
+		hosts:    make(map[string]*host),
+		onChange: onChange,
+	}
+}
+
+// UpdateConfig применяет новые параметры; текущие исключения сохраняются
+func (d *Detector) UpdateConfig(cfg Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cfg = normalize(cfg)
+}
+
+// SetBackends задает список бэкендов из конфигурации. Состояние удаленных бэкендов сбрасывается.
+func (d *Detector) SetBackends(backends []backend.Backend) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	known := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		known[b.URL] = true
+	}
+	for url := range d.hosts {
+		if !known[url] {
+			delete(d.hosts, url)
+		}
+	}
+	d.total = len(backends)
+}
+
+// Ejected сообщает, исключен ли бэкенд, и до какого времени
+func (d *Detector) Ejected(backend string) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h, ok := d.hosts[backend]
+	if !ok || h.ejectedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return h.ejectedAt.Add(d.ejectionTime(h.ejections)), true
+}
+
+func (d *Detector) Excluded(backend string) bool {
+	_, ejected := d.Ejected(backend)
+	return ejected
+}
+
+func (d *Detector) Admit(string) bool {
+	return true
+}
+
+// Observe учитывает итог запроса к бэкенду
+func (d *Detector) Observe(backend string, res balancer.Result) {
+	if res.Status == 0 && !res.Failed() {
+		return // Попытка без ответа бэкенда и без его вины (например, отменена клиентом)
+	}
+
+	d.mu.Lock()
+	h, ok := d.hosts[backend]
+	if !ok {
+		h = &host{}
+		d.hosts[backend] = h
+	}
+	if !h.ejectedAt.IsZero() {
+		d.mu.Unlock()
+		return // Запросы, начатые до исключения, не учитываются
+	}
+
+	now := time.Now()
+	if now.Sub(h.windowStart) >= d.cfg.Interval {
+		// Интервал без исключения постепенно снимает историю исключений
+		if !h.windowStart.IsZero() && h.ejections > 0 {
+			h.ejections--
+		}
+		h.windowStart, h.requests, h.errors5xx = now, 0, 0
+	}
+
+	h.requests++
+	switch {
+	case res.Err != nil:
+		h.consecutive++
+	case res.Status >= 500:
+		h.consecutive = 0
+		h.errors5xx++
+	default:
+		h.consecutive = 0
+	}
+
+	reason := ""
+	switch {
+	case d.cfg.ConsecutiveErrors > 0 && h.consecutive >= d.cfg.ConsecutiveErrors:
+		reason = "consecutive connection errors"
+	case d.cfg.ErrorRatePercent > 0 && h.requests >= d.cfg.MinRequests &&
+		h.errors5xx*100 >= h.requests*d.cfg.ErrorRatePercent:
+		reason = "5xx error rate"
+	}
+	ejected := reason != "" && d.eject(backend, h, reason, now)
+	d.mu.Unlock()
+
+	if ejected {
+		d.onChange()
+	}
+}
+
+// eject исключает бэкенд, если это позволяет MaxEjectionPercent. Вызывается под d.mu.
+func (d *Detector) eject(backend string, h *host, reason string, now time.Time) bool {
+	ejected := 0
+	for _, other := range d.hosts {
+		if !other.ejectedAt.IsZero() {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > d.total*d.cfg.MaxEjectionPercent {
+		slog.Warn("Outlier detection: ejection skipped, too many backends ejected",
+			slog.String("backend", backend),
+			slog.String("reason", reason),
+			slog.Int("ejected", ejected))
+		h.consecutive, h.windowStart = 0, time.Time{}
+		return false
+	}
+
+	h.ejections++
+	h.ejectedAt = now
+	h.consecutive, h.windowStart = 0, time.Time{}
+	duration := d.ejectionTime(h.ejections)
+	slog.Warn("Outlier detection: backend ejected",
+		slog.String("backend", backend),
+		slog.String("reason", reason),
+		slog.Duration("duration", duration))
+
+	time.AfterFunc(duration, func() {
+		d.mu.Lock()
+		if d.hosts[backend] != h || !h.ejectedAt.Equal(now) {
+			d.mu.Unlock()
+			return
+		}
+		h.ejectedAt = time.Time{}
+		d.mu.Unlock()
+
+		slog.Info("Outlier detection: backend returned", slog.String("backend", backend))
+		d.onChange()
+	})
+	return true
+}
+
+// ejectionTime BaseEjectionTime * 2^(ejections-1), не больше MaxEjectionTime
+func (d *Detector) ejectionTime(ejections int) time.Duration {
+	t := d.cfg.BaseEjectionTime
+	for i := 1; i < ejections && t < d.cfg.MaxEjectionTime; i++ {
+		t *= 2
+	}
+	return min(t, d.cfg.MaxEjectionTime)
+}
+
+func normalize(cfg Config) Config {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.BaseEjectionTime <= 0 {
+		cfg.BaseEjectionTime = 30 * time.Second
+	}
+	if cfg.MaxEjectionTime < cfg.BaseEjectionTime {
+		cfg.MaxEjectionTime = cfg.BaseEjectionTime
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 1
+	}
+	return cfg
+}
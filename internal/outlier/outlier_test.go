@@ -0,0 +1,84 @@
+package outlier
+
+import (
+	"errors"
+	"load-balancer/internal/backend"
+	"load-balancer/internal/balancer"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var connErr = balancer.Result{Err: errors.New("connection refused")}
+
+func newDetector(cfg Config, backends ...string) *Detector {
+	d := New(cfg, nil)
+	list := make([]backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		list = append(list, backend.Backend{URL: b})
+	}
+	d.SetBackends(list)
+	return d
+}
+
+func TestEjectOnConsecutiveErrors(t *testing.T) {
+	d := newDetector(Config{ConsecutiveErrors: 2, MaxEjectionPercent: 50, BaseEjectionTime: time.Minute}, "a", "b")
+
+	d.Observe("a", connErr)
+	if d.Excluded("a") {
+		t.Fatal("ejected before threshold")
+	}
+	d.Observe("a", connErr)
+	if !d.Excluded("a") {
+		t.Fatal("expected ejection after 2 connection errors")
+	}
+
+	// Второй бэкенд не исключается: доля исключенных превысила бы 50%
+	d.Observe("b", connErr)
+	d.Observe("b", connErr)
+	if d.Excluded("b") {
+		t.Fatal("max ejection percent not enforced")
+	}
+}
+
+func TestEjectOn5xxRate(t *testing.T) {
+	d := newDetector(Config{ErrorRatePercent: 50, MinRequests: 4, MaxEjectionPercent: 100}, "a")
+
+	d.Observe("a", balancer.Result{Status: http.StatusOK})
+	d.Observe("a", balancer.Result{Status: http.StatusBadGateway})
+	d.Observe("a", balancer.Result{Status: http.StatusOK})
+	if d.Excluded("a") {
+		t.Fatal("ejected before min requests")
+	}
+	d.Observe("a", balancer.Result{Status: http.StatusInternalServerError})
+	if !d.Excluded("a") {
+		t.Fatal("expected ejection at 50% 5xx")
+	}
+}
+
+func TestEjectionTimeGrowsExponentially(t *testing.T) {
+	changed := make(chan struct{}, 1)
+	d := New(Config{
+		ConsecutiveErrors:  1,
+		MaxEjectionPercent: 100,
+		BaseEjectionTime:   10 * time.Millisecond,
+		MaxEjectionTime:    30 * time.Millisecond,
+	}, func() { changed <- struct{}{} })
+	d.SetBackends([]backend.Backend{{URL: "a"}})
+
+	for i, want := range []time.Duration{10, 20, 30, 30} {
+		d.Observe("a", connErr)
+		<-changed // Исключение
+		until, ok := d.Ejected("a")
+		if !ok {
+			t.Fatalf("ejection %d: not ejected", i+1)
+		}
+		if got := time.Until(until); got > want*time.Millisecond || got < want*time.Millisecond/2 {
+			t.Fatalf("ejection %d: duration %v, want about %v", i+1, got, want*time.Millisecond)
+		}
+		<-changed // Возврат
+		if d.Excluded("a") {
+			t.Fatalf("ejection %d: still ejected", i+1)
+		}
+	}
+}
@@ -1,80 +1,78 @@
+/*
+Пакет prettylog настраивает slog:
+- Форматы json, text (стандартные обработчики slog) и pretty (цветной вывод для консоли)
+- Уровень логирования через общий slog.LevelVar, меняется без перезапуска
+- Необязательное место вызова (source) в записях
+*/
+
 package prettylog
 
 import (
-	"context"
-	"encoding/json"
-	"github.com/fatih/color"
+	"fmt"
 	"io"
-	"log"
 	"log/slog"
 	"os"
+	"strings"
 )
 
-type PrettyHandlerOptions struct {
-	SlogOpts slog.HandlerOptions
-}
-
-type PrettyHandler struct {
-	slog.Handler
-	l *log.Logger
-}
-
-func (h *PrettyHandler) Handle(ctx context.Context, r slog.Record) error {
-	level := r.Level.String() + ":"
-
-	switch r.Level {
-	case slog.LevelDebug:
-		level = color.MagentaString(level)
-	case slog.LevelInfo:
-		level = color.BlueString(level)
-	case slog.LevelWarn:
-		level = color.YellowString(level)
-	case slog.LevelError:
-		level = color.RedString(level)
-	}
-
-	fields := make(map[string]interface{}, r.NumAttrs())
-	r.Attrs(func(a slog.Attr) bool {
-		fields[a.Key] = a.Value.Any()
+// Форматы логов
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatPretty = "pretty"
+)
 
-		return true
-	})
+// level уровень всех обработчиков, созданных через Init
+var level slog.LevelVar
 
-	//b, err := json.MarshalIndent(fields, "", "  ")
-	b, err := json.Marshal(fields)
-	if err != nil {
-		return err
+// ParseLevel разбирает уровень логирования: debug, info, warn, error. Неизвестный уровень - info.
+func ParseLevel(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
+}
 
-	timeStr := r.Time.Format("[15:05:05.000]")
-	msg := color.CyanString(r.Message)
-
-	h.l.Println(timeStr, level, msg, color.WhiteString(string(b)))
-
-	return nil
+// SetLevel меняет уровень логирования на лету
+func SetLevel(logLevel string) {
+	level.Set(ParseLevel(logLevel))
 }
 
-func NewPrettyHandler(
-	out io.Writer,
-	opts PrettyHandlerOptions,
-) *PrettyHandler {
-	h := &PrettyHandler{
-		Handler: slog.NewJSONHandler(out, &opts.SlogOpts),
-		l:       log.New(out, "", 0),
+// NewHandler создает обработчик в формате format
+func NewHandler(out io.Writer, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case FormatJSON:
+		return slog.NewJSONHandler(out, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(out, opts), nil
+	case FormatPretty, "":
+		return NewPrettyHandler(out, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
 	}
-
-	return h
 }
 
-func InitLogger(logLevel string) {
-	opts := PrettyHandlerOptions{}
+// Init устанавливает логгер по умолчанию с выводом в out.
+// При неизвестном формате используется pretty и возвращается ошибка.
+func Init(out io.Writer, format, logLevel string, addSource bool) error {
+	SetLevel(logLevel)
+	opts := &slog.HandlerOptions{Level: &level, AddSource: addSource}
 
-	switch logLevel {
-	case "debug":
-		opts.SlogOpts = slog.HandlerOptions{Level: slog.LevelDebug}
-	default:
-		opts.SlogOpts = slog.HandlerOptions{Level: slog.LevelInfo}
+	h, err := NewHandler(out, format, opts)
+	if err != nil {
+		h = NewPrettyHandler(out, opts)
 	}
+	slog.SetDefault(slog.New(h))
+	return err
+}
 
-	slog.SetDefault(slog.New(NewPrettyHandler(os.Stdout, opts)))
+// InitLogger устанавливает цветной логгер в stdout
+func InitLogger(logLevel string) {
+	_ = Init(os.Stdout, FormatPretty, logLevel, false)
 }
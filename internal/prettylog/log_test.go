@@ -1,15 +1,23 @@
 package prettylog_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/fatih/color"
 	"load-balancer/internal/prettylog"
 	"log/slog"
+	"math"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogger_Init(t *testing.T) {
 
 	prettylog.InitLogger("debug")
-	
+
 	slog.Debug(
 		"executing database query",
 		slog.String("query", "SELECT * FROM users"),
@@ -24,3 +32,110 @@ func TestLogger_Init(t *testing.T) {
 		slog.String("url", "https://example.com"),
 	)
 }
+
+func TestPrettyHandler(t *testing.T) {
+	color.NoColor = true
+	var buf bytes.Buffer
+	h := prettylog.NewPrettyHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+
+	logger := slog.New(h).With("service", "lb").WithGroup("req").With("method", "GET")
+	logger.Info("done",
+		slog.Int("status", 200),
+		slog.Duration("took", 15*time.Millisecond),
+		slog.Group("backend", slog.String("url", "http://a")),
+		slog.Any("err", errors.New("boom")),
+	)
+	logger.Debug("filtered out")
+	slog.New(h).WithGroup("empty").Info("no attrs")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+
+	re := regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2}\.\d{3}\] INFO: done (\{.*\})$`)
+	m := re.FindStringSubmatch(lines[0])
+	if m == nil {
+		t.Fatalf("unexpected line %q", lines[0])
+	}
+	want := `{"service":"lb","req":{"method":"GET","status":200,"took":"15ms","backend":{"url":"http://a"},"err":"boom"}}`
+	if m[1] != want {
+		t.Errorf("fields:\n got %s\nwant %s", m[1], want)
+	}
+	if !json.Valid([]byte(m[1])) {
+		t.Error("fields are not valid JSON")
+	}
+
+	// Группа без атрибутов не выводится
+	if !strings.HasSuffix(lines[1], "INFO: no attrs") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+}
+
+func TestPrettyHandlerEscaping(t *testing.T) {
+	color.NoColor = true
+	var buf bytes.Buffer
+	h := prettylog.NewPrettyHandler(&buf, nil)
+
+	slog.New(h).Info("escape",
+		slog.String("ctrl", "a\x00b\x1b[31m\n\"q\"\\"),
+		slog.String("bad\x01key", "\xff<html>"),
+		slog.Float64("nan", math.NaN()),
+		slog.Float64("inf", math.Inf(-1)),
+		slog.Float64("pi", 3.5),
+	)
+
+	line := strings.TrimSpace(buf.String())
+	fields := line[strings.Index(line, "{"):]
+	want := `{"ctrl":"a\u0000b\u001b[31m\n\"q\"\\","bad\u0001key":"\ufffd<html>","nan":"NaN","inf":"-Inf","pi":3.5}`
+	if fields != want {
+		t.Errorf("fields:\n got %s\nwant %s", fields, want)
+	}
+	if !json.Valid([]byte(fields)) {
+		t.Error("fields are not valid JSON")
+	}
+}
+
+func TestPrettyHandlerSource(t *testing.T) {
+	color.NoColor = true
+	var buf bytes.Buffer
+	slog.New(prettylog.NewPrettyHandler(&buf, &slog.HandlerOptions{AddSource: true})).Info("here")
+
+	if !strings.Contains(buf.String(), "prettylog/log_test.go:") {
+		t.Errorf("source location missing: %q", buf.String())
+	}
+}
+
+func TestInitFormatsAndLevel(t *testing.T) {
+	defer prettylog.InitLogger("info")
+
+	var buf bytes.Buffer
+	if err := prettylog.Init(&buf, prettylog.FormatJSON, "info", false); err != nil {
+		t.Fatal(err)
+	}
+	slog.Debug("hidden")
+	prettylog.SetLevel("debug") // Уровень меняется без пересоздания логгера
+	slog.Debug("visible", slog.String("k", "v"))
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one JSON record, got %q", buf.String())
+	}
+	if rec["msg"] != "visible" || rec["k"] != "v" {
+		t.Errorf("record = %v", rec)
+	}
+
+	buf.Reset()
+	if err := prettylog.Init(&buf, prettylog.FormatText, "warn", false); err != nil {
+		t.Fatal(err)
+	}
+	slog.Info("hidden")
+	slog.Warn("shown")
+	if got := buf.String(); !strings.Contains(got, "level=WARN msg=shown") || strings.Contains(got, "hidden") {
+		t.Errorf("text output = %q", got)
+	}
+
+	if err := prettylog.Init(&buf, "xml", "info", false); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,275 @@
+package prettylog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// PrettyHandler выводит запись одной строкой для чтения в консоли:
+//
+//	[15:04:05.000] INFO: message {"key":"value","group":{"key":1}}
+//
+// Атрибуты из WithAttrs и группы из WithGroup сохраняются в порядке добавления.
+type PrettyHandler struct {
+	opts slog.HandlerOptions
+	mu   *sync.Mutex // Общий для обработчиков, созданных через WithAttrs/WithGroup
+	out  io.Writer
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs группа из WithGroup или атрибуты из WithAttrs
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+func NewPrettyHandler(out io.Writer, opts *slog.HandlerOptions) *PrettyHandler {
+	h := &PrettyHandler{out: out, mu: &sync.Mutex{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Level == nil {
+		h.opts.Level = slog.LevelInfo
+	}
+	return h
+}
+
+func (h *PrettyHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.opts.Level.Level()
+}
+
+func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+func (h *PrettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+func (h *PrettyHandler) with(goa groupOrAttrs) *PrettyHandler {
+	h2 := *h
+	h2.goas = append(append(make([]groupOrAttrs, 0, len(h.goas)+1), h.goas...), goa)
+	return &h2
+}
+
+func (h *PrettyHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.Enabled(ctx, r.Level) {
+		return nil
+	}
+
+	buf := make([]byte, 0, 256)
+	if !r.Time.IsZero() {
+		buf = append(buf, r.Time.Format("[15:04:05.000]")...)
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, levelString(r.Level)...)
+	buf = append(buf, ' ')
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := frames.Next()
+		src := filepath.Base(filepath.Dir(f.File)) + "/" + filepath.Base(f.File) + ":" + strconv.Itoa(f.Line)
+		buf = append(buf, color.HiBlackString(src)...)
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, color.CyanString(r.Message)...)
+
+	fields := h.appendFields(nil, r)
+	if len(fields) > 0 {
+		buf = append(buf, ' ')
+		buf = append(buf, color.WhiteString("%s", fields)...)
+	}
+	buf = append(buf, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.out.Write(buf)
+	return err
+}
+
+// appendFields собирает атрибуты обработчика и записи в JSON-объект. Пусто - атрибутов нет.
+func (h *PrettyHandler) appendFields(buf []byte, r slog.Record) []byte {
+	goas := h.goas
+	if r.NumAttrs() == 0 {
+		// Группы без атрибутов не выводятся
+		for len(goas) > 0 && goas[len(goas)-1].group != "" {
+			goas = goas[:len(goas)-1]
+		}
+	}
+
+	buf = append(buf, '{')
+	start := len(buf)
+	var groups []string
+	for _, goa := range goas {
+		if goa.group != "" {
+			buf = appendKey(buf, start, goa.group)
+			buf = append(buf, '{')
+			start = len(buf)
+			groups = append(groups, goa.group)
+			continue
+		}
+		for _, a := range goa.attrs {
+			buf = h.appendAttr(buf, start, a, groups)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		buf = h.appendAttr(buf, start, a, groups)
+		return true
+	})
+	for range groups {
+		buf = append(buf, '}')
+	}
+	buf = append(buf, '}')
+
+	if string(buf) == "{}" {
+		return nil
+	}
+	return buf
+}
+
+// appendAttr добавляет атрибут в объект, начатый с позиции start (для расстановки запятых).
+// groups - вложенные группы атрибута для ReplaceAttr.
+func (h *PrettyHandler) appendAttr(buf []byte, start int, a slog.Attr, groups []string) []byte {
+	a.Value = a.Value.Resolve()
+	if rep := h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
+		a = rep(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return buf
+		}
+		if a.Key == "" {
+			// Группа без имени встраивается в текущий объект
+			for _, ga := range attrs {
+				buf = h.appendAttr(buf, start, ga, groups)
+			}
+			return buf
+		}
+		buf = appendKey(buf, start, a.Key)
+		buf = append(buf, '{')
+		inner := len(buf)
+		inGroup := append(groups[:len(groups):len(groups)], a.Key)
+		for _, ga := range attrs {
+			buf = h.appendAttr(buf, inner, ga, inGroup)
+		}
+		return append(buf, '}')
+	}
+
+	buf = appendKey(buf, start, a.Key)
+	return appendValue(buf, a.Value)
+}
+
+func appendKey(buf []byte, start int, key string) []byte {
+	if len(buf) > start {
+		buf = append(buf, ',')
+	}
+	buf = appendString(buf, key)
+	return append(buf, ':')
+}
+
+func appendValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		// NaN и ±Inf в JSON не представимы - выводим строкой
+		if f := v.Float64(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return appendString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(buf, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return appendString(buf, v.Duration().String())
+	case slog.KindTime:
+		return appendString(buf, v.Time().Format(time.RFC3339Nano))
+	}
+
+	switch x := v.Any().(type) {
+	case error:
+		return appendString(buf, x.Error())
+	case fmt.Stringer:
+		return appendString(buf, x.String())
+	}
+	b, err := json.Marshal(v.Any())
+	if err != nil {
+		return appendString(buf, fmt.Sprintf("%+v", v.Any()))
+	}
+	return append(buf, b...)
+}
+
+// appendString добавляет строку в кавычках по правилам JSON.
+// В отличие от json.Marshal не экранирует <, > и &, чтобы строка оставалась читаемой.
+func appendString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20 || c == 0x7f:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, `\ufffd`...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+func levelString(l slog.Level) string {
+	s := l.String() + ":"
+	switch {
+	case l < slog.LevelInfo:
+		return color.MagentaString(s)
+	case l < slog.LevelWarn:
+		return color.BlueString(s)
+	case l < slog.LevelError:
+		return color.YellowString(s)
+	default:
+		return color.RedString(s)
+	}
+}
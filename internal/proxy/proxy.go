@@ -1,11 +1,226 @@
+/*
+Пакет proxy реализует:
+- Пул reverse proxy с отдельным транспортом на каждый бэкенд
+- Создание транспорта при добавлении бэкенда и закрытие при удалении
+- Настройку таймаутов и keep-alive из конфигурации
+- TLS и mTLS к бэкендам https: общие параметры и параметры отдельных бэкендов
+- Передачу обработчиков конкретного запроса через контекст
+*/
+
 package proxy
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"load-balancer/internal/upstreamtls"
+	"log/slog"
+	"net"
+	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sync"
+	"time"
 )
 
-func NewReverseProxy(target string) *httputil.ReverseProxy {
-	targetURL, _ := url.Parse(target)
-	return httputil.NewSingleHostReverseProxy(targetURL)
+// TransportConfig параметры соединений с бэкендами
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	DisableKeepAlives     bool
+	TLS                   upstreamtls.Config // Для бэкендов https; файлы читаются при создании Upstream и ReloadTLS
+}
+
+// Hooks обработчики конкретного запроса. ReverseProxy общий для всех запросов к бэкенду,
+// поэтому обработчики передаются через контекст запроса.
+type Hooks struct {
+	ModifyResponse func(*http.Response) error
+	ErrorHandler   func(http.ResponseWriter, *http.Request, error)
+}
+
+type hooksKey struct{}
+
+// Upstream reverse proxy к одному бэкенду со своим пулом соединений
+type Upstream struct {
+	URL       *url.URL
+	proxy     *httputil.ReverseProxy
+	transport *http.Transport
+	cfg       TransportConfig // С учетом TLS бэкенда
+}
+
+// Serve проксирует запрос на бэкенд с обработчиками hooks
+func (u *Upstream) Serve(w http.ResponseWriter, r *http.Request, hooks Hooks) {
+	ctx := context.WithValue(r.Context(), hooksKey{}, &hooks)
+	u.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (u *Upstream) close() {
+	u.transport.CloseIdleConnections()
+}
+
+// Pool хранит Upstream для каждого бэкенда из конфигурации
+type Pool struct {
+	mu        sync.RWMutex
+	upstreams map[string]*Upstream
+}
+
+func NewPool() *Pool {
+	return &Pool{
+		upstreams: make(map[string]*Upstream),
+	}
+}
+
+// Get возвращает Upstream бэкенда
+func (p *Pool) Get(backend string) (*Upstream, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	u, ok := p.upstreams[backend]
+	return u, ok
+}
+
+// Update приводит пул к списку бэкендов: создает Upstream для новых и закрывает для удаленных.
+// backendTLS заменяет cfg.TLS для отдельных бэкендов (URL -> параметры).
+// При изменении параметров транспорта бэкенда его Upstream пересоздается.
+// Активные запросы через закрытые Upstream завершаются штатно.
+// Возвращает ошибки разбора URL бэкендов и чтения файлов TLS: новые такие бэкенды в пул не попадают,
+// у существующих остается прежний Upstream.
+func (p *Pool) Update(cfg TransportConfig, backends []string, backendTLS map[string]upstreamtls.Config) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	upstreams := make(map[string]*Upstream, len(backends))
+	for _, b := range backends {
+		bcfg := cfg
+		if t, ok := backendTLS[b]; ok {
+			bcfg.TLS = t
+		}
+
+		old, ok := p.upstreams[b]
+		if ok && old.cfg == bcfg {
+			upstreams[b] = old
+			continue
+		}
+
+		u, err := newUpstream(b, bcfg)
+		if err != nil {
+			errs = append(errs, err)
+			if ok {
+				upstreams[b] = old
+			}
+			continue
+		}
+		upstreams[b] = u
+		slog.Debug("Proxy: upstream created", slog.String("backend", b))
+	}
+
+	for b, u := range p.upstreams {
+		if upstreams[b] != u {
+			u.close()
+			slog.Debug("Proxy: upstream closed", slog.String("backend", b))
+		}
+	}
+	p.upstreams = upstreams
+
+	return errors.Join(errs...)
+}
+
+// ReloadTLS пересоздает Upstream бэкендов с параметрами TLS, чтобы перечитать файлы
+// сертификатов и CA, замененные по тому же пути. Активные запросы завершаются штатно.
+// При ошибке чтения у бэкенда остается прежний Upstream.
+func (p *Pool) ReloadTLS() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for b, u := range p.upstreams {
+		if u.cfg.TLS == (upstreamtls.Config{}) {
+			continue
+		}
+		nu, err := newUpstream(b, u.cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		u.close()
+		p.upstreams[b] = nu
+	}
+	return errors.Join(errs...)
+}
+
+// Close закрывает соединения всех Upstream
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, u := range p.upstreams {
+		u.close()
+	}
+	p.upstreams = make(map[string]*Upstream)
+}
+
+func newUpstream(backend string, cfg TransportConfig) (*Upstream, error) {
+	targetURL, err := url.Parse(backend)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend URL %q: %w", backend, err)
+	}
+	if targetURL.Scheme == "" || targetURL.Host == "" {
+		return nil, fmt.Errorf("invalid backend URL %q: scheme and host are required", backend)
+	}
+
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("backend %q: %w", backend, err)
+	}
+	p := httputil.NewSingleHostReverseProxy(targetURL)
+	p.Transport = transport
+	p.ModifyResponse = func(resp *http.Response) error {
+		if h := hooksFrom(resp.Request.Context()); h != nil && h.ModifyResponse != nil {
+			return h.ModifyResponse(resp)
+		}
+		return nil
+	}
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if h := hooksFrom(r.Context()); h != nil && h.ErrorHandler != nil {
+			h.ErrorHandler(w, r, err)
+			return
+		}
+		slog.Error("Error proxying request", slog.String("backend", backend), slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return &Upstream{URL: targetURL, proxy: p, transport: transport, cfg: cfg}, nil
+}
+
+func newTransport(cfg TransportConfig) (*http.Transport, error) {
+	tlsConfig, err := upstreamtls.New(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		TLSClientConfig:       tlsConfig,
+	}, nil
+}
+
+func hooksFrom(ctx context.Context) *Hooks {
+	h, _ := ctx.Value(hooksKey{}).(*Hooks)
+	return h
 }
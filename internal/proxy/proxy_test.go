@@ -0,0 +1,243 @@
+package proxy_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"load-balancer/internal/config"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/upstreamtls"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoolUpdate(t *testing.T) {
+	pool := proxy.NewPool()
+	cfg := proxy.TransportConfig{MaxIdleConnsPerHost: 10, IdleConnTimeout: time.Minute}
+
+	err := pool.Update(cfg, []string{"http://localhost:9001", "http://localhost:9002", "://bad"}, nil)
+	if err == nil {
+		t.Error("expected error for invalid backend URL")
+	}
+	a, ok := pool.Get("http://localhost:9001")
+	if !ok {
+		t.Fatal("upstream for http://localhost:9001 not created")
+	}
+	if _, ok := pool.Get("://bad"); ok {
+		t.Error("upstream for invalid URL created")
+	}
+
+	// Неизмененный бэкенд сохраняет свой Upstream, удаленный - исчезает
+	if err := pool.Update(cfg, []string{"http://localhost:9001"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := pool.Get("http://localhost:9001"); got != a {
+		t.Error("upstream recreated without config change")
+	}
+	if _, ok := pool.Get("http://localhost:9002"); ok {
+		t.Error("removed backend still in pool")
+	}
+
+	// Изменение параметров транспорта пересоздает Upstream
+	cfg.DisableKeepAlives = true
+	if err := pool.Update(cfg, []string{"http://localhost:9001"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := pool.Get("http://localhost:9001"); got == a {
+		t.Error("upstream not recreated after transport config change")
+	}
+}
+
+func TestUpstreamServeHooks(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+
+	pool := proxy.NewPool()
+	defer pool.Close()
+	if err := pool.Update(proxy.TransportConfig{}, []string{backend.URL}, nil); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := pool.Get(backend.URL)
+
+	called := false
+	w := httptest.NewRecorder()
+	u.Serve(w, httptest.NewRequest(http.MethodGet, "/", nil), proxy.Hooks{
+		ModifyResponse: func(resp *http.Response) error {
+			called = true
+			resp.Header.Set("X-Test", "1")
+			return nil
+		},
+	})
+
+	if !called || w.Header().Get("X-Test") != "1" || w.Body.String() != "ok" {
+		t.Errorf("hooks not applied: called=%v headers=%v body=%q", called, w.Header(), w.Body.String())
+	}
+}
+
+// writePEM записывает блоки PEM в файл dir/name и возвращает путь
+func writePEM(t *testing.T, dir, name string, blocks ...*pem.Block) string {
+	t.Helper()
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, pem.EncodeToMemory(b)...)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clientCert создает самоподписанный клиентский сертификат cn в dir/client.crt и dir/client.key
+// (поверх прежних файлов) и возвращает пути сертификата и ключа
+func clientCert(t *testing.T, dir, cn string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = writePEM(t, dir, "client.crt", &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyFile = writePEM(t, dir, "client.key", &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certFile, keyFile, cert
+}
+
+func TestUpstreamMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := clientCert(t, dir, "lb-client")
+
+	var serverName string
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverName = r.TLS.ServerName
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	backend.TLS = &tls.Config{ClientCAs: clientCAs, ClientAuth: tls.RequireAndVerifyClientCert}
+	backend.StartTLS()
+	defer backend.Close()
+
+	caFile := writePEM(t, dir, "ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: backend.Certificate().Raw})
+
+	pool := proxy.NewPool()
+	defer pool.Close()
+	serve := func() *httptest.ResponseRecorder {
+		u, ok := pool.Get(backend.URL)
+		if !ok {
+			t.Fatal("upstream not created")
+		}
+		w := httptest.NewRecorder()
+		u.Serve(w, httptest.NewRequest(http.MethodGet, "/", nil), proxy.Hooks{})
+		return w
+	}
+
+	// Без клиентского сертификата и своего CA бэкенд недоступен
+	if err := pool.Update(proxy.TransportConfig{}, []string{backend.URL}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(); w.Code != http.StatusBadGateway {
+		t.Errorf("without TLS config: status %d, want 502", w.Code)
+	}
+
+	// Параметры бэкенда заменяют общие; сертификат httptest выдан на example.com
+	backendTLS := map[string]upstreamtls.Config{backend.URL: {
+		CAFile:     caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ServerName: "example.com",
+	}}
+	if err := pool.Update(proxy.TransportConfig{}, []string{backend.URL}, backendTLS); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(); w.Code != http.StatusOK || w.Body.String() != "lb-client" {
+		t.Errorf("mTLS: status %d, body %q", w.Code, w.Body.String())
+	}
+	if serverName != "example.com" {
+		t.Errorf("SNI = %q, want example.com", serverName)
+	}
+
+	// Ошибка чтения файлов не удаляет рабочий Upstream
+	u, _ := pool.Get(backend.URL)
+	broken := map[string]upstreamtls.Config{backend.URL: {CAFile: filepath.Join(dir, "missing.pem")}}
+	if err := pool.Update(proxy.TransportConfig{}, []string{backend.URL}, broken); err == nil {
+		t.Error("expected error for missing CA bundle")
+	}
+	if got, _ := pool.Get(backend.URL); got != u {
+		t.Error("upstream replaced after failed update")
+	}
+}
+
+func TestUpstreamClientCertRotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := clientCert(t, dir, "before")
+
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	backend.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	backend.StartTLS()
+	defer backend.Close()
+	caFile := writePEM(t, dir, "ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: backend.Certificate().Raw})
+
+	pool := proxy.NewPool()
+	defer pool.Close()
+	cfg := proxy.TransportConfig{TLS: upstreamtls.Config{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}}
+	if err := pool.Update(cfg, []string{backend.URL}, nil); err != nil {
+		t.Fatal(err)
+	}
+	presented := func() string {
+		u, _ := pool.Get(backend.URL)
+		w := httptest.NewRecorder()
+		u.Serve(w, httptest.NewRequest(http.MethodGet, "/", nil), proxy.Hooks{})
+		return w.Body.String()
+	}
+	if got := presented(); got != "before" {
+		t.Fatalf("presented %q, want before", got)
+	}
+
+	// Так же, как в балансировщике: изменение файлов перечитывает сертификаты
+	err := config.WatchFiles(t.Context(), []string{caFile, certFile, keyFile}, 50*time.Millisecond, func() {
+		_ = pool.ReloadTLS()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Сертификат перезаписывается по тому же пути, параметры пула не меняются
+	clientCert(t, dir, "after")
+	deadline := time.Now().Add(5 * time.Second)
+	for presented() != "after" {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated client certificate was not presented")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
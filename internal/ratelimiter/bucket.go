@@ -10,9 +10,28 @@ type Bucket struct {
 	Tokens       int
 	RefillRate   int // tokens per second
 	LastRefilled time.Time
+	Rule         string // Правило лимита: RuleDefault или персональные настройки клиента
 	mu           sync.Mutex
 }
 
+// BucketState снимок бакета клиента
+type BucketState struct {
+	ClientID   string    `json:"client_id"`
+	Rule       string    `json:"rule"`
+	Capacity   int       `json:"capacity"`
+	Tokens     int       `json:"tokens"`
+	RefillRate int       `json:"rate_per_second"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// tokens возвращает текущее число токенов с учетом пополнения, не расходуя их
+func (b *Bucket) tokens() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newTokens := int(time.Since(b.LastRefilled).Seconds() * float64(b.RefillRate))
+	return min(b.Capacity, b.Tokens+newTokens)
+}
+
 func (b *Bucket) allow() bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -2,7 +2,10 @@ package ratelimiter
 
 import (
 	"encoding/json"
+	"go.opentelemetry.io/otel/trace"
 	"load-balancer/internal/apperror"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/tracing"
 	"load-balancer/internal/utils/userkey"
 	"log/slog"
 	"net/http"
@@ -12,21 +15,33 @@ func Middleware(rl *Limiter, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cip, err := userkey.ReqToIP(r)
 		if err != nil {
-			slog.Info("Error parsing userkey-IP header")
+			slog.InfoContext(r.Context(), "Error parsing userkey-IP header")
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(apperror.ErrUnauthorized.Code)
-			json.NewEncoder(w).Encode(apperror.ErrUnauthorized)
+			json.NewEncoder(w).Encode(apperror.ErrUnauthorized.WithRequestID(requestid.FromContext(r.Context())))
 			//http.Error(w, apperror.ErrUnauthorized.Message, apperror.ErrUnauthorized.Code)
 			return
 		}
 
-		if !rl.Allow(cip.Value()) {
-			slog.Info("Rate limit exceeded", slog.String("cip", cip.Value()))
+		d := rl.Decide(cip.Value())
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			tracing.AttrRateLimitDecision.String(decision(d)),
+			tracing.AttrRateLimitRule.String(d.Rule),
+		)
+		if !d.Allowed {
+			slog.InfoContext(r.Context(), "Rate limit exceeded", slog.String("cip", cip.Value()))
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(apperror.ErrTooManyRequests.Code)
-			json.NewEncoder(w).Encode(apperror.ErrTooManyRequests)
+			json.NewEncoder(w).Encode(apperror.ErrTooManyRequests.WithRequestID(requestid.FromContext(r.Context())))
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+func decision(d Decision) string {
+	if d.Allowed {
+		return "allow"
+	}
+	return "deny"
+}
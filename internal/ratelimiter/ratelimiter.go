@@ -24,6 +24,16 @@ type RateLimiter interface {
 	Allow(string) bool
 }
 
+// RuleDefault правило лимита для клиентов без персональных настроек;
+// для клиентов из client_overrides правило - "client:<client_id>"
+const RuleDefault = "default"
+
+// Decision решение rate limiter по запросу
+type Decision struct {
+	Allowed bool
+	Rule    string // Правило, по которому создан бакет клиента
+}
+
 type Limiter struct {
 	buckets         map[string]*Bucket
 	lastSeen        map[string]time.Time
@@ -31,6 +41,7 @@ type Limiter struct {
 
 	defaultCapacity int
 	defaultRate     int
+	observers       []func(Decision) // Обработчики решений (например, для метрик)
 	mu              sync.RWMutex
 
 	// Для управления циклом очистки
@@ -98,9 +109,11 @@ func (l *Limiter) getBucket(clientID string) *Bucket {
 	// Определяем параметры для нового бакета
 	capacity := currentDefaultCapacity
 	rate := currentDefaultRate
+	rule := RuleDefault
 	if hasOverride {
 		capacity = clientSpecificConfig.Capacity
 		rate = clientSpecificConfig.Rate
+		rule = "client:" + clientID
 	}
 
 	newBucket := &Bucket{
@@ -108,6 +121,7 @@ func (l *Limiter) getBucket(clientID string) *Bucket {
 		Tokens:       capacity,
 		RefillRate:   rate,
 		LastRefilled: time.Now(),
+		Rule:         rule,
 	}
 
 	l.buckets[clientID] = newBucket
@@ -122,8 +136,78 @@ func (l *Limiter) getBucket(clientID string) *Bucket {
 }
 
 func (l *Limiter) Allow(clientID string) bool {
+	return l.Decide(clientID).Allowed
+}
+
+// Decide расходует токен клиента и возвращает решение вместе с правилом
+func (l *Limiter) Decide(clientID string) Decision {
 	b := l.getBucket(clientID)
-	return b.allow()
+	d := Decision{Allowed: b.allow(), Rule: b.Rule}
+
+	l.mu.RLock()
+	observers := l.observers
+	l.mu.RUnlock()
+	for _, fn := range observers {
+		fn(d)
+	}
+	return d
+}
+
+// OnDecision добавляет обработчик решений. Обработчики вызываются на каждый запрос и не должны блокироваться.
+func (l *Limiter) OnDecision(fn func(Decision)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observers = append(l.observers, fn)
+}
+
+// Buckets возвращает снимки всех бакетов
+func (l *Limiter) Buckets() []BucketState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	states := make([]BucketState, 0, len(l.buckets))
+	for cid, b := range l.buckets {
+		states = append(states, l.state(cid, b))
+	}
+	return states
+}
+
+// Bucket возвращает снимок бакета клиента. false - бакета нет (клиент не обращался или бакет очищен).
+func (l *Limiter) Bucket(clientID string) (BucketState, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	b, ok := l.buckets[clientID]
+	if !ok {
+		return BucketState{}, false
+	}
+	return l.state(clientID, b), true
+}
+
+// Reset удаляет бакет клиента: следующий запрос создаст полный бакет по текущей конфигурации
+func (l *Limiter) Reset(clientID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.buckets[clientID]; !ok {
+		return false
+	}
+	delete(l.buckets, clientID)
+	delete(l.lastSeen, clientID)
+	slog.Info("RateLimiter: bucket reset", slog.String("client_id", clientID))
+	return true
+}
+
+// state вызывается под l.mu
+func (l *Limiter) state(clientID string, b *Bucket) BucketState {
+	return BucketState{
+		ClientID:   clientID,
+		Rule:       b.Rule,
+		Capacity:   b.Capacity,
+		Tokens:     b.tokens(),
+		RefillRate: b.RefillRate,
+		LastSeen:   l.lastSeen[clientID],
+	}
 }
 
 func (l *Limiter) StartCleanup(parentCtx context.Context, interval time.Duration, ttl time.Duration) {
@@ -21,6 +21,54 @@ func Test_Init(t *testing.T) {
 	t.Log(rlCfg.ClientOverrides[0])
 }
 
+func Test_Reset(t *testing.T) {
+	limiter := ratelimiter.NewLimiter(2, 0, nil)
+
+	limiter.Allow("id1")
+	limiter.Allow("id1")
+	if limiter.Allow("id1") {
+		t.Fatal("bucket should be empty")
+	}
+	if b, ok := limiter.Bucket("id1"); !ok || b.Tokens != 0 || b.Capacity != 2 {
+		t.Fatalf("bucket = %+v, %v", b, ok)
+	}
+
+	if !limiter.Reset("id1") {
+		t.Fatal("reset of existing bucket returned false")
+	}
+	if _, ok := limiter.Bucket("id1"); ok {
+		t.Fatal("bucket not removed")
+	}
+	if !limiter.Allow("id1") {
+		t.Fatal("request denied after reset")
+	}
+}
+
+func Test_Decide(t *testing.T) {
+	limiter := ratelimiter.NewLimiter(1, 0, map[string]ratelimiter.ClientConfig{"vip": {Capacity: 1}})
+
+	var decisions []ratelimiter.Decision
+	limiter.OnDecision(func(d ratelimiter.Decision) { decisions = append(decisions, d) })
+
+	limiter.Decide("id1")
+	limiter.Decide("id1")
+	limiter.Decide("vip")
+
+	want := []ratelimiter.Decision{
+		{Allowed: true, Rule: ratelimiter.RuleDefault},
+		{Allowed: false, Rule: ratelimiter.RuleDefault},
+		{Allowed: true, Rule: "client:vip"},
+	}
+	if len(decisions) != len(want) {
+		t.Fatalf("decisions = %+v", decisions)
+	}
+	for i := range want {
+		if decisions[i] != want[i] {
+			t.Errorf("decision %d = %+v, want %+v", i, decisions[i], want[i])
+		}
+	}
+}
+
 func Test_Allow(t *testing.T) {
 	limiter := ratelimiter.NewLimiter(6, 1, nil)
 
@@ -0,0 +1,188 @@
+/*
+Пакет requestid реализует идентификатор запроса (X-Request-ID):
+- Генерацию ID или прием входящего от доверенных источников
+- Передачу ID бэкенду и клиенту
+- Добавление ID в записи slog через контекст запроса
+*/
+
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Header заголовок с ID запроса
+const Header = "X-Request-ID"
+
+// maxLength максимальная длина входящего ID; более длинный заменяется новым
+const maxLength = 128
+
+type idKey struct{}
+
+// New генерирует случайный ID (128 бит в hex)
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// FromContext возвращает ID текущего запроса или "", если его нет
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// WithID добавляет ID запроса в контекст
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// Trusted сети, входящему X-Request-ID от которых можно доверять.
+// Сравнивается адрес непосредственного клиента (прокси перед балансировщиком), а не X-Forwarded-For.
+type Trusted struct {
+	nets atomic.Pointer[[]*net.IPNet]
+}
+
+// ParseTrusted разбирает список адресов и подсетей ("10.0.0.1", "10.0.0.0/8")
+func ParseTrusted(list []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted source %q", s)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted source %q", s)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// Set заменяет доверенные сети
+func (t *Trusted) Set(nets []*net.IPNet) {
+	t.nets.Store(&nets)
+}
+
+// Contains проверяет, что адрес клиента (host:port или IP) из доверенной сети
+func (t *Trusted) Contains(remoteAddr string) bool {
+	if t == nil {
+		return false
+	}
+	nets := t.nets.Load()
+	if nets == nil {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range *nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware назначает запросу ID: входящий X-Request-ID принимается, только если запрос пришел
+// из доверенной сети, иначе генерируется новый. ID передается бэкенду в заголовке запроса,
+// возвращается клиенту в заголовке ответа и доступен через FromContext.
+func Middleware(trusted *Trusted, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) || !trusted.Contains(r.RemoteAddr) {
+			id = New()
+		}
+		r.Header.Set(Header, id)
+
+		next.ServeHTTP(&responseWriter{ResponseWriter: w, id: id}, r.WithContext(WithID(r.Context(), id)))
+	})
+}
+
+// valid допускает непустые ID из печатных ASCII-символов без пробелов
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// responseWriter выставляет X-Request-ID перед отправкой заголовков, заменяя значение от бэкенда
+type responseWriter struct {
+	http.ResponseWriter
+	id          string
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set(Header, w.id)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap нужен http.ResponseController (Flush при проксировании потоковых ответов)
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LogHandler добавляет request_id из контекста в записи slog.
+// Записи без контекста запроса (slog.Info вместо slog.InfoContext) не меняются.
+func LogHandler(h slog.Handler) slog.Handler {
+	return logHandler{h}
+}
+
+type logHandler struct {
+	slog.Handler
+}
+
+func (h logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,85 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	nets, err := ParseTrusted([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted := &Trusted{}
+	trusted.Set(nets)
+
+	var forwarded, fromContext string
+	h := Middleware(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(Header)
+		fromContext = FromContext(r.Context())
+		w.Header().Set(Header, "from-backend") // Заменяется ID балансировщика
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	tests := []struct {
+		name     string
+		remote   string
+		incoming string
+		keep     bool
+	}{
+		{"trusted subnet", "10.1.2.3:5000", "abc-123", true},
+		{"trusted address", "192.168.1.5:5000", "abc-123", true},
+		{"untrusted", "192.168.1.6:5000", "abc-123", false},
+		{"no incoming id", "10.1.2.3:5000", "", false},
+		{"invalid id", "10.1.2.3:5000", "bad id", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.incoming != "" {
+				req.Header.Set(Header, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			id := w.Header().Get(Header)
+			if tt.keep != (id == tt.incoming) {
+				t.Errorf("response id = %q, incoming %q, keep = %v", id, tt.incoming, tt.keep)
+			}
+			if id == "" || forwarded != id || fromContext != id {
+				t.Errorf("response %q, forwarded %q, context %q must match", id, forwarded, fromContext)
+			}
+		})
+	}
+}
+
+func TestParseTrusted(t *testing.T) {
+	if _, err := ParseTrusted([]string{"not-an-ip"}); err == nil {
+		t.Error("expected error")
+	}
+	if (*Trusted)(nil).Contains("10.0.0.1:1") || (&Trusted{}).Contains("10.0.0.1:1") {
+		t.Error("empty trusted list must not trust anyone")
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(LogHandler(slog.NewTextHandler(&buf, nil))).With("component", "test")
+
+	logger.InfoContext(WithID(context.Background(), "rid-1"), "with id")
+	logger.Info("without id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "request_id=rid-1") || strings.Contains(lines[1], "request_id") {
+		t.Errorf("log = %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "component=test") {
+		t.Error("attributes from With are lost")
+	}
+}
@@ -11,65 +11,294 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"load-balancer/internal/accesslog"
 	"load-balancer/internal/apperror"
 	"load-balancer/internal/balancer"
+	"load-balancer/internal/metrics"
 	"load-balancer/internal/proxy"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/sticky"
+	"load-balancer/internal/tracing"
 	"load-balancer/internal/utils/userkey"
 	"log/slog"
 	"net/http"
-	"net/url"
+	"sync/atomic"
+	"time"
 )
 
 type Handler struct {
-	balancer balancer.Balancer
+	balancer balancer.TrackingBalancer
+	proxies  *proxy.Pool
+	sticky   *sticky.Sessions            // nil, если sticky-сессии выключены
+	retry    atomic.Pointer[RetryPolicy] // nil - без повторов
+	metrics  *metrics.Metrics            // nil, если метрики выключены
+	tracer   trace.Tracer                // nil, если трассировка выключена
+	strategy func() string               // Имя текущей стратегии для атрибутов спана
 }
 
-func NewHandler(b balancer.Balancer) *Handler {
-	return &Handler{balancer: b}
+// HandlerOption дополнительная настройка Handler
+type HandlerOption func(*Handler)
+
+// WithStickySessions включает привязку клиентов к бэкендам через cookie
+func WithStickySessions(s *sticky.Sessions) HandlerOption {
+	return func(h *Handler) {
+		h.sticky = s
+	}
+}
+
+// WithRetryPolicy включает повтор запросов на другом бэкенде
+func WithRetryPolicy(p *RetryPolicy) HandlerOption {
+	return func(h *Handler) {
+		h.retry.Store(p)
+	}
+}
+
+// WithMetrics включает метрики запросов к бэкендам
+func WithMetrics(m *metrics.Metrics) HandlerOption {
+	return func(h *Handler) {
+		h.metrics = m
+	}
+}
+
+// WithTracing включает клиентские спаны на каждую попытку проксирования и передачу
+// контекста трассировки бэкендам. Серверный спан создает tracing.Middleware.
+func WithTracing(tracer trace.Tracer, strategy func() string) HandlerOption {
+	return func(h *Handler) {
+		h.tracer = tracer
+		h.strategy = strategy
+	}
+}
+
+func NewHandler(b balancer.TrackingBalancer, proxies *proxy.Pool, options ...HandlerOption) *Handler {
+	h := &Handler{balancer: b, proxies: proxies}
+	for _, op := range options {
+		op(h)
+	}
+	return h
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// индикация пользователя (userkey-IP)
 	cip, _ := userkey.ReqToIP(r)
 	attr := slog.String(cip.Type(), cip.Value())
-	slog.Info("Request", attr)
+	slog.InfoContext(r.Context(), "Request", attr)
+
+	span := trace.SpanFromContext(r.Context())
+	if h.tracer != nil {
+		span.SetAttributes(tracing.AttrStrategy.String(h.strategy()))
+	}
 
-	backend, err := h.balancer.Next()
+	backend, pinned := h.pinnedBackend(r)
+	var err error
+	if !pinned {
+		backend, err = h.balancer.Next(cip.Value())
+	}
 
 	if errors.Is(err, balancer.ErrNoHealthyBackends) {
-		slog.Error("No backend available", attr)
-		jsonError(w, apperror.ErrNoBackendAvailable)
+		slog.ErrorContext(r.Context(), "No backend available", attr)
+		jsonError(w, r, apperror.ErrNoBackendAvailable)
 		return
 	}
 
 	if err != nil {
-		slog.Error("Balancer error", slog.String("error", err.Error()), attr)
-		jsonError(w, apperror.ErrStatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Balancer error", slog.String("error", err.Error()), attr)
+		jsonError(w, r, apperror.ErrStatusInternalServerError)
 		return
 	}
 
-	targetURL, err := url.Parse(backend)
-	if err != nil {
-		slog.Error(
-			"Invalid backend URL",
-			slog.String("url", backend),
-			slog.String("error", err.Error()),
+	retry := newRetryState(h.retry.Load(), r)
+	attempts := 0
+	for backend != "" {
+		attempts++
+		if h.tracer != nil {
+			span.SetAttributes(tracing.AttrBackend.String(backend))
+		}
+		backend = h.forward(w, r, backend, pinned, retry, attempts, cip.Value(), attr)
+		pinned = false
+	}
+
+	if h.tracer != nil {
+		span.SetAttributes(tracing.AttrRetryCount.Int(attempts - 1))
+	}
+	if e := accesslog.FromContext(r.Context()); e != nil {
+		e.Retries = attempts - 1
+	}
+}
+
+// SetRetryPolicy заменяет политику повторов (nil - без повторов)
+func (h *Handler) SetRetryPolicy(p *RetryPolicy) {
+	h.retry.Store(p)
+}
+
+// forward выполняет одну попытку проксирования запроса на backend.
+// Возвращает бэкенд для повторной попытки или "", если ответ уже отдан клиенту.
+func (h *Handler) forward(
+	w http.ResponseWriter,
+	r *http.Request,
+	backend string,
+	pinned bool,
+	retry *retryState,
+	attempt int,
+	key string,
+	attr slog.Attr,
+) (next string) {
+	if retry != nil {
+		retry.tried = append(retry.tried, backend)
+		retry.rewind(r)
+	}
+
+	var span trace.Span
+	if h.tracer != nil {
+		r, span = h.startAttempt(r, backend, attempt)
+	}
+	var inFlightDone func()
+	if h.metrics != nil {
+		inFlightDone = h.metrics.RequestStarted(backend)
+	}
+
+	// Сообщаем балансировщику о завершении запроса (в т.ч. при ошибке проксирования).
+	// Задержкой считается время до получения заголовков ответа бэкенда,
+	// без учета передачи тела клиенту.
+	start := time.Now()
+	res := balancer.Result{Start: start}
+	defer func() {
+		if res.RTT == 0 {
+			res.RTT = time.Since(start)
+		}
+		h.balancer.Done(backend, res)
+
+		if h.metrics != nil {
+			inFlightDone()
+			h.metrics.ObserveRequest(backend, r.Method, res.Status, res.Err != nil, res.RTT)
+		}
+		if span != nil {
+			endAttempt(span, res)
+		}
+		if e := accesslog.FromContext(r.Context()); e != nil {
+			e.Upstream = backend
+			e.UpstreamLatency = res.RTT
+		}
+	}()
+
+	upstream, ok := h.proxies.Get(backend)
+	if !ok {
+		// Бэкенда нет в пуле: некорректный URL в конфигурации или он только что удален
+		slog.ErrorContext(r.Context(), "No upstream for backend", slog.String("url", backend), attr)
+		jsonError(w, r, apperror.ErrStatusInternalServerError)
+		return ""
+	}
+
+	// nextBackend выбирает другой бэкенд, если повтор разрешен политикой.
+	// Решение принимается до записи ответа клиенту: если повторить нельзя,
+	// клиент получает ответ (или ошибку) текущей попытки.
+	nextBackend := func(reason string) string {
+		if !retry.canRetry(r) {
+			return ""
+		}
+		b, err := h.balancer.NextExcluding(key, retry.tried)
+		if err != nil {
+			return ""
+		}
+		slog.WarnContext(
+			r.Context(),
+			"Retrying request on another backend",
+			slog.String("backend", backend),
+			slog.String("next_backend", b),
+			slog.String("reason", reason),
+			slog.Int("attempt", len(retry.tried)+1),
 			attr,
 		)
-		jsonError(w, apperror.ErrStatusInternalServerError)
-		return
+		return b
+	}
+
+	slog.InfoContext(r.Context(), "Backend available", slog.String("server_url", upstream.URL.String()), attr)
+	upstream.Serve(w, r, proxy.Hooks{
+		ModifyResponse: func(resp *http.Response) error {
+			res.RTT = time.Since(start)
+			res.Status = resp.StatusCode
+			if retry.retryableStatus(resp.StatusCode) {
+				if next = nextBackend(resp.Status); next != "" {
+					return errRetryableStatus
+				}
+			}
+			if h.sticky != nil && !pinned {
+				// Закрепляем клиента за бэкендом, который успешно ответил
+				resp.Header.Add("Set-Cookie", h.sticky.Cookie(r, backend).String())
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, errRetryableStatus) {
+				return // Бэкенд для повтора уже выбран в ModifyResponse
+			}
+			res.Err = err
+			if retry.retryableError(err) {
+				if next = nextBackend(err.Error()); next != "" {
+					return
+				}
+			}
+			h.proxyErrorHandler(backend)(w, r, err)
+		},
+	})
+	return next
+}
+
+// startAttempt начинает клиентский спан попытки и передает контекст трассировки бэкенду.
+// Возвращает копию запроса: заголовки исходного запроса не меняются между попытками.
+func (h *Handler) startAttempt(r *http.Request, backend string, attempt int) (*http.Request, trace.Span) {
+	ctx, span := h.tracer.Start(r.Context(), r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			tracing.AttrBackend.String(backend),
+			tracing.AttrAttempt.Int(attempt),
+		),
+	)
+	out := r.Clone(ctx)
+	tracing.Inject(ctx, out.Header)
+	return out, span
+}
+
+func endAttempt(span trace.Span, res balancer.Result) {
+	if res.Status != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(res.Status))
+		tracing.SetStatus(span, res.Status)
+	}
+	if res.Err != nil {
+		span.RecordError(res.Err)
+		span.SetStatus(codes.Error, res.Err.Error())
+	}
+	span.End()
+}
+
+// pinnedBackend возвращает бэкенд из sticky-cookie, если он все еще жив.
+// Иначе запрос уходит в стратегию балансировки, а клиент получит новую cookie.
+func (h *Handler) pinnedBackend(r *http.Request) (string, bool) {
+	if h.sticky == nil {
+		return "", false
 	}
 
-	slog.Info("Backend available", slog.String("server_url", targetURL.String()), attr)
-	p := proxy.NewReverseProxy(targetURL.String())
-	p.ErrorHandler = h.proxyErrorHandler(targetURL.String())
-	p.ServeHTTP(w, r)
+	backend, ok := h.sticky.Backend(r)
+	if !ok {
+		return "", false
+	}
+
+	if !h.balancer.Pin(backend) {
+		slog.DebugContext(r.Context(), "Sticky backend is not available, falling back to strategy",
+			slog.String("backend", backend))
+		return "", false
+	}
+	return backend, true
 }
 
 func (h *Handler) proxyErrorHandler(backend string) func(http.ResponseWriter, *http.Request, error) {
 	return func(w http.ResponseWriter, r *http.Request, err error) {
-		slog.Error("Error proxying request", slog.String("error", err.Error()))
-		jsonError(w, apperror.ErrStatusBadGateway)
+		slog.ErrorContext(r.Context(), "Error proxying request",
+			slog.String("backend", backend), slog.String("error", err.Error()))
+		jsonError(w, r, apperror.ErrStatusBadGateway)
 	}
 }
 
@@ -77,8 +306,9 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func jsonError(w http.ResponseWriter, appError *apperror.AppError) {
+// jsonError отвечает ошибкой с ID запроса, чтобы ее можно было сопоставить с логами
+func jsonError(w http.ResponseWriter, r *http.Request, appError *apperror.AppError) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(appError.Code)
-	json.NewEncoder(w).Encode(appError.Message)
+	json.NewEncoder(w).Encode(appError.WithRequestID(requestid.FromContext(r.Context())))
 }
@@ -0,0 +1,139 @@
+package server_test
+
+import (
+	"encoding/json"
+	"io"
+	"load-balancer/internal/apperror"
+	"load-balancer/internal/backend"
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestHandler создает Handler с round-robin по backends в заданном порядке
+func newTestHandler(t *testing.T, policy *server.RetryPolicy, backends ...string) *server.Handler {
+	t.Helper()
+
+	list := make([]backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		list = append(list, backend.Backend{URL: b})
+	}
+	ab := balancer.NewAtomicBalancer(balancer.NewRoundRobin(nil), nil)
+	ab.Update(list)
+
+	pool := proxy.NewPool()
+	t.Cleanup(pool.Close)
+	if err := pool.Update(proxy.TransportConfig{}, backends, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return server.NewHandler(ab, pool, server.WithRetryPolicy(policy))
+}
+
+func TestHandlerRetry(t *testing.T) {
+	var bodies []string
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer ok.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close() // Соединение с этим адресом не установится
+
+	policy, err := server.NewRetryPolicy(3, []string{"connect-error", "503"}, []string{"GET", "PUT"}, 1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("status", func(t *testing.T) {
+		bodies = nil
+		h := newTestHandler(t, policy, unavailable.URL, ok.URL)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload")))
+
+		if w.Code != http.StatusOK || w.Body.String() != "ok" {
+			t.Errorf("expected retry to succeed, got %d %q", w.Code, w.Body.String())
+		}
+		if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+			t.Errorf("request body not replayed: %q", bodies)
+		}
+	})
+
+	t.Run("connect error", func(t *testing.T) {
+		h := newTestHandler(t, policy, closed.URL, ok.URL)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected retry to succeed, got %d", w.Code)
+		}
+	})
+
+	t.Run("non idempotent", func(t *testing.T) {
+		h := newTestHandler(t, policy, unavailable.URL, ok.URL)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("POST must not be retried, got %d", w.Code)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		bodies = nil
+		h := newTestHandler(t, policy, unavailable.URL, ok.URL)
+		w := httptest.NewRecorder()
+		large := strings.Repeat("x", 2048)
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(large)))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("request with large body must not be retried, got %d", w.Code)
+		}
+		if len(bodies) != 1 || bodies[0] != large {
+			t.Error("large body not streamed to backend intact")
+		}
+	})
+
+	t.Run("no other backend", func(t *testing.T) {
+		h := newTestHandler(t, policy, unavailable.URL)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		// Повторить негде: клиент получает ответ бэкенда как есть
+		if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "down") {
+			t.Errorf("expected upstream response, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestHandlerErrorHasRequestID(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	h := requestid.Middleware(nil, newTestHandler(t, nil, closed.URL))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body apperror.AppError
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	id := w.Header().Get(requestid.Header)
+	if w.Code != http.StatusBadGateway || id == "" || body.RequestID != id {
+		t.Errorf("status %d, header id %q, body %+v", w.Code, id, body)
+	}
+}
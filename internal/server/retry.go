@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Условия повтора в конфигурации
+const (
+	RetryOnConnectError = "connect-error"
+)
+
+// DefaultRetryMethods идемпотентные методы, которые повторяются по умолчанию
+var DefaultRetryMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions,
+	http.MethodPut, http.MethodDelete, http.MethodTrace,
+}
+
+// errRetryableStatus возвращается из ModifyResponse, чтобы ReverseProxy не отдал ответ клиенту
+var errRetryableStatus = errors.New("retryable upstream status")
+
+// RetryPolicy описывает повтор запроса на другом бэкенде
+type RetryPolicy struct {
+	MaxAttempts    int             // Всего попыток, включая первую
+	OnConnectError bool            // Повторять при ошибке соединения с бэкендом
+	OnStatus       map[int]bool    // Повторять при этих статусах ответа бэкенда
+	Methods        map[string]bool // Методы, которые можно повторять
+	MaxBodyBytes   int64           // Тело больше этого размера не буферизуется, запрос не повторяется
+	Budget         time.Duration   // Время с начала запроса, после которого повторы не начинаются; 0 - без ограничения
+}
+
+// NewRetryPolicy разбирает настройки повторов. retryOn - "connect-error" и/или коды статусов ("502").
+// Если methods пустой, повторяются только идемпотентные методы.
+func NewRetryPolicy(
+	maxAttempts int,
+	retryOn, methods []string,
+	maxBodyBytes int64,
+	budget time.Duration,
+) (*RetryPolicy, error) {
+	p := &RetryPolicy{
+		MaxAttempts:  maxAttempts,
+		OnStatus:     make(map[int]bool),
+		Methods:      make(map[string]bool),
+		MaxBodyBytes: maxBodyBytes,
+		Budget:       budget,
+	}
+
+	for _, cond := range retryOn {
+		if cond == RetryOnConnectError {
+			p.OnConnectError = true
+			continue
+		}
+		code, err := strconv.Atoi(cond)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid retry condition %q", cond)
+		}
+		p.OnStatus[code] = true
+	}
+
+	if len(methods) == 0 {
+		methods = DefaultRetryMethods
+	}
+	for _, m := range methods {
+		p.Methods[strings.ToUpper(m)] = true
+	}
+
+	return p, nil
+}
+
+// retryState состояние повторов одного запроса
+type retryState struct {
+	policy   *RetryPolicy
+	deadline time.Time // Нулевое время - без ограничения по времени
+	body     []byte    // Буферизованное тело для повторной отправки
+	tried    []string  // Бэкенды, на которые уже уходил запрос
+}
+
+// newRetryState подготавливает запрос к повторам. Возвращает nil, если запрос повторять нельзя.
+// Тело запроса буферизуется до policy.MaxBodyBytes; если оно больше, запрос
+// отправляется один раз без повторов, а тело передается потоком.
+func newRetryState(policy *RetryPolicy, r *http.Request) *retryState {
+	if policy == nil || policy.MaxAttempts <= 1 || !policy.Methods[r.Method] {
+		return nil
+	}
+
+	s := &retryState{policy: policy}
+	if policy.Budget > 0 {
+		s.deadline = time.Now().Add(policy.Budget)
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return s
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, policy.MaxBodyBytes+1))
+	if err != nil || int64(len(buf)) > policy.MaxBodyBytes {
+		// Возвращаем уже прочитанное, остаток тела читается из исходного потока
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		return nil
+	}
+	_ = r.Body.Close()
+
+	s.body = buf
+	s.rewind(r)
+	return s
+}
+
+// rewind подготавливает тело запроса к очередной попытке
+func (s *retryState) rewind(r *http.Request) {
+	if s.body == nil {
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(s.body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(s.body)), nil
+	}
+}
+
+// canRetry проверяет, остались ли попытки и время на повтор
+func (s *retryState) canRetry(r *http.Request) bool {
+	if s == nil || len(s.tried) >= s.policy.MaxAttempts || r.Context().Err() != nil {
+		return false
+	}
+	return s.deadline.IsZero() || time.Now().Before(s.deadline)
+}
+
+func (s *retryState) retryableStatus(code int) bool {
+	return s != nil && s.policy.OnStatus[code]
+}
+
+func (s *retryState) retryableError(err error) bool {
+	return s != nil && s.policy.OnConnectError && isConnectError(err)
+}
+
+// isConnectError ошибка установки соединения: запрос до бэкенда не дошел
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -11,33 +11,33 @@ import (
 	"time"
 )
 
-func Run(appCtx context.Context, appCancel context.CancelFunc, s *http.Server) {
-
-	serverErrChan := make(chan error, 1)
-	//go func() {
-	slog.Info("HTTP server starting", slog.String("address", s.Addr))
-	if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		slog.Error("HTTP server ListenAndServe error", slog.String("error", err.Error()))
+// Run запускает серверы и останавливает их по сигналу, отмене appCtx или ошибке любого из них.
+// Сервер с TLSConfig принимает HTTPS, сертификаты берутся из TLSConfig.
+func Run(appCtx context.Context, appCancel context.CancelFunc, servers ...*http.Server) {
+	serverErrChan := make(chan error, len(servers))
+	for _, s := range servers {
 		go func() {
-			serverErrChan <- err
-			close(serverErrChan)
+			if err := listen(s); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("HTTP server ListenAndServe error",
+					slog.String("address", s.Addr), slog.String("error", err.Error()))
+				serverErrChan <- err
+			}
 		}()
 	}
 
-	//}()
-
-	//go func() {
-	//slog.Info("server started", slog.String("addr", s.Addr))
-	//if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-	//	slog.Error(err.Error())
-	//	panic(err)
-	//}
-	//}()
+	gracefulShutdown(appCtx, appCancel, servers, serverErrChan)
+}
 
-	gracefulShutdown(appCtx, appCancel, s, serverErrChan)
+func listen(s *http.Server) error {
+	if s.TLSConfig != nil {
+		slog.Info("HTTPS server starting", slog.String("address", s.Addr))
+		return s.ListenAndServeTLS("", "")
+	}
+	slog.Info("HTTP server starting", slog.String("address", s.Addr))
+	return s.ListenAndServe()
 }
 
-func gracefulShutdown(appCtx context.Context, appCancel context.CancelFunc, s *http.Server, serverErrChan chan error) {
+func gracefulShutdown(appCtx context.Context, appCancel context.CancelFunc, servers []*http.Server, serverErrChan chan error) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -61,10 +61,12 @@ func gracefulShutdown(appCtx context.Context, appCancel context.CancelFunc, s *h
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer shutdownCancel()
 
-	if err := s.Shutdown(shutdownCtx); err != nil {
-		slog.Error("server shutdown error", slog.String("error", err.Error()))
-	} else {
-		slog.Info("HTTP server gracefully stopped.")
+	for _, s := range servers {
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			slog.Error("server shutdown error", slog.String("address", s.Addr), slog.String("error", err.Error()))
+		} else {
+			slog.Info("HTTP server gracefully stopped.", slog.String("address", s.Addr))
+		}
 	}
 
 	slog.Info("server exiting")
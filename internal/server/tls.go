@@ -0,0 +1,167 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// CertificateFiles пара файлов сертификата и ключа в формате PEM
+type CertificateFiles struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Certificates сертификаты HTTPS-порта. Набор заменяется без перезапуска (Reload),
+// каждое рукопожатие получает актуальный сертификат через GetCertificate.
+type Certificates struct {
+	files []CertificateFiles
+	certs atomic.Pointer[[]tls.Certificate]
+}
+
+// LoadCertificates загружает сертификаты; первый используется по умолчанию
+func LoadCertificates(files []CertificateFiles) (*Certificates, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no certificates configured")
+	}
+	c := &Certificates{files: files}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload перечитывает файлы. При ошибке остаются прежние сертификаты.
+func (c *Certificates) Reload() error {
+	certs := make([]tls.Certificate, 0, len(c.files))
+	for _, f := range c.files {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return fmt.Errorf("certificate %s: %w", f.CertFile, err)
+		}
+		certs = append(certs, cert)
+	}
+	c.certs.Store(&certs)
+	return nil
+}
+
+// Files возвращает пути всех файлов сертификатов и ключей для наблюдения за изменениями
+func (c *Certificates) Files() []string {
+	paths := make([]string, 0, 2*len(c.files))
+	for _, f := range c.files {
+		paths = append(paths, f.CertFile, f.KeyFile)
+	}
+	return paths
+}
+
+// GetCertificate выбирает по SNI первый сертификат, подходящий клиенту
+// (имя, алгоритмы подписи, версия). Если подходящего нет - первый из списка.
+func (c *Certificates) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certs := *c.certs.Load()
+	for i := range certs {
+		if hello.SupportsCertificate(&certs[i]) == nil {
+			return &certs[i], nil
+		}
+	}
+	return &certs[0], nil
+}
+
+// TLSOptions параметры рукопожатия HTTPS-порта
+type TLSOptions struct {
+	MinVersion   string   // 1.0 | 1.1 | 1.2 | 1.3; пусто - 1.2
+	CipherSuites []string // Имена из crypto/tls для TLS 1.2 и ниже; пусто - набор Go по умолчанию
+	DisableHTTP2 bool     // ALPN предлагает только http/1.1
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig собирает tls.Config с выбором сертификата по SNI.
+// Небезопасные наборы шифров (RC4, 3DES, CBC-SHA256) не принимаются.
+func NewTLSConfig(certs *Certificates, o TLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	if o.MinVersion != "" {
+		v, ok := tlsVersions[o.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version %q (available: 1.0, 1.1, 1.2, 1.3)", o.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+
+	for _, name := range o.CipherSuites {
+		id, err := cipherSuite(name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CipherSuites = append(cfg.CipherSuites, id)
+	}
+
+	if o.DisableHTTP2 {
+		cfg.NextProtos = []string{"http/1.1"}
+	}
+	return cfg, nil
+}
+
+func cipherSuite(name string) (uint16, error) {
+	for _, s := range tls.CipherSuites() {
+		if s.Name == name {
+			return s.ID, nil
+		}
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		if s.Name == name {
+			return 0, fmt.Errorf("insecure cipher suite %s", name)
+		}
+	}
+	return 0, fmt.Errorf("unknown cipher suite %s", name)
+}
+
+// NewTLSServer создает HTTPS-сервер на addr с обработчиком и таймаутами сервера plain.
+// HTTP/2 включается, только если tlsConfig предлагает h2.
+func NewTLSServer(plain *http.Server, addr string, tlsConfig *tls.Config) *http.Server {
+	s := &http.Server{
+		Addr:         addr,
+		Handler:      plain.Handler,
+		ReadTimeout:  plain.ReadTimeout,
+		WriteTimeout: plain.WriteTimeout,
+		TLSConfig:    tlsConfig,
+	}
+	if !slices.Contains(tlsConfig.NextProtos, "h2") {
+		// Непустой TLSNextProto отключает автоматическую настройку HTTP/2
+		s.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	return s
+}
+
+// RedirectHTTPS отвечает постоянным редиректом на тот же хост и путь по HTTPS на порту httpsPort.
+// Код 308 сохраняет метод и тело запроса.
+func RedirectHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
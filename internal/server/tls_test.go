@@ -0,0 +1,247 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"load-balancer/internal/config"
+	"load-balancer/internal/server"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert создает самоподписанный сертификат для name в dir и возвращает пути файлов
+func writeCert(t *testing.T, dir, name, file string) (server.CertificateFiles, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := server.CertificateFiles{
+		CertFile: filepath.Join(dir, file+".crt"),
+		KeyFile:  filepath.Join(dir, file+".key"),
+	}
+	// Запись через временный файл и rename, как при выкладке сертификатов
+	write := func(path string, block *pem.Block) {
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(f.KeyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	write(f.CertFile, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return f, cert
+}
+
+// startTLS запускает HTTPS-сервер и возвращает его адрес
+func startTLS(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	s := server.NewTLSServer(plain, ln.Addr().String(), tlsConfig)
+	go s.ServeTLS(ln, "", "")
+	t.Cleanup(func() { s.Close() })
+	return ln.Addr().String()
+}
+
+func get(t *testing.T, addr, serverName string, roots *x509.CertPool) *http.Response {
+	t.Helper()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{ServerName: serverName, RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+	t.Cleanup(client.CloseIdleConnections)
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestTLSServerSNIAndALPN(t *testing.T) {
+	dir := t.TempDir()
+	fa, ca := writeCert(t, dir, "a.example.com", "a")
+	fb, cb := writeCert(t, dir, "b.example.com", "b")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	roots.AddCert(cb)
+
+	certs, err := server.LoadCertificates([]server.CertificateFiles{fa, fb})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name         string
+		disableHTTP2 bool
+		proto        string
+	}{
+		{"h2", false, "HTTP/2.0"},
+		{"http1", true, "HTTP/1.1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := server.NewTLSConfig(certs, server.TLSOptions{MinVersion: "1.2", DisableHTTP2: tc.disableHTTP2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr := startTLS(t, cfg)
+
+			for _, name := range []string{"a.example.com", "b.example.com"} {
+				resp := get(t, addr, name, roots)
+				if got := resp.TLS.PeerCertificates[0].Subject.CommonName; got != name {
+					t.Errorf("SNI %s: got certificate for %s", name, got)
+				}
+				if resp.Proto != tc.proto {
+					t.Errorf("proto = %s, want %s", resp.Proto, tc.proto)
+				}
+			}
+		})
+	}
+
+	// Без подходящего имени выбирается первый сертификат
+	hello := &tls.ClientHelloInfo{ServerName: "unknown.example.com"}
+	got, err := certs.GetCertificate(hello)
+	if err != nil || got.Leaf.Subject.CommonName != "a.example.com" {
+		t.Errorf("fallback certificate = %v, %v", got.Leaf.Subject.CommonName, err)
+	}
+}
+
+func TestCertificatesReloadOnChange(t *testing.T) {
+	dir := t.TempDir()
+	f, _ := writeCert(t, dir, "old.example.com", "site")
+	certs, err := server.LoadCertificates([]server.CertificateFiles{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan error, 10)
+	err = config.WatchFiles(t.Context(), certs.Files(), 50*time.Millisecond, func() {
+		reloaded <- certs.Reload()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeCert(t, dir, "new.example.com", "site")
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificates were not reloaded")
+	}
+
+	got, _ := certs.GetCertificate(&tls.ClientHelloInfo{})
+	if got.Leaf.Subject.CommonName != "new.example.com" {
+		t.Errorf("certificate = %s after reload", got.Leaf.Subject.CommonName)
+	}
+
+	// Битый файл не заменяет рабочий сертификат
+	if err := os.WriteFile(f.CertFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := certs.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+	got, _ = certs.GetCertificate(&tls.ClientHelloInfo{})
+	if got.Leaf.Subject.CommonName != "new.example.com" {
+		t.Errorf("certificate = %s after failed reload", got.Leaf.Subject.CommonName)
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	f, _ := writeCert(t, t.TempDir(), "a.example.com", "a")
+	certs, err := server.LoadCertificates([]server.CertificateFiles{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := server.NewTLSConfig(certs, server.TLSOptions{
+		MinVersion:   "1.3",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MinVersion != tls.VersionTLS13 || len(cfg.CipherSuites) != 1 ||
+		cfg.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("unexpected config: min %x, ciphers %v", cfg.MinVersion, cfg.CipherSuites)
+	}
+
+	for _, o := range []server.TLSOptions{
+		{MinVersion: "1.4"},
+		{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		{CipherSuites: []string{"TLS_NO_SUCH_SUITE"}},
+	} {
+		if _, err := server.NewTLSConfig(certs, o); err == nil {
+			t.Errorf("expected error for %+v", o)
+		}
+	}
+
+	if _, err := server.LoadCertificates(nil); err == nil {
+		t.Error("expected error without certificates")
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	for _, tc := range []struct {
+		port, host, target, want string
+	}{
+		{"8443", "example.com:8080", "/a?b=1", "https://example.com:8443/a?b=1"},
+		{"443", "example.com", "/", "https://example.com/"},
+		{"443", "[::1]:8080", "/x", "https://[::1]/x"},
+		{"8443", "[::1]", "/x", "https://[::1]:8443/x"},
+	} {
+		r := httptest.NewRequest(http.MethodPost, tc.target, nil)
+		r.Host = tc.host
+		w := httptest.NewRecorder()
+		server.RedirectHTTPS(tc.port).ServeHTTP(w, r)
+
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != tc.want {
+			t.Errorf("%s%s: got %d %q, want %q", tc.host, tc.target, w.Code, w.Header().Get("Location"), tc.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"load-balancer/internal/backend"
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/server"
+	"load-balancer/internal/tracing"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerTracingAndMetrics(t *testing.T) {
+	var traceparents []string
+	record := func(code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			traceparents = append(traceparents, r.Header.Get("traceparent"))
+			w.WriteHeader(code)
+		}
+	}
+	unavailable := httptest.NewServer(record(http.StatusServiceUnavailable))
+	defer unavailable.Close()
+	ok := httptest.NewServer(record(http.StatusOK))
+	defer ok.Close()
+
+	backends := []string{unavailable.URL, ok.URL}
+	ab := balancer.NewAtomicBalancer(balancer.NewRoundRobin(nil), nil)
+	ab.Update([]backend.Backend{{URL: unavailable.URL}, {URL: ok.URL}})
+	pool := proxy.NewPool()
+	defer pool.Close()
+	if err := pool.Update(proxy.TransportConfig{}, backends, nil); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := server.NewRetryPolicy(2, []string{"503"}, nil, 1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewProvider(exporter, 1, "test")
+	defer tp.Shutdown(t.Context())
+	m := metrics.New()
+
+	h := server.NewHandler(ab, pool,
+		server.WithRetryPolicy(policy),
+		server.WithMetrics(m),
+		server.WithTracing(tp.Tracer(tracing.TracerName), func() string { return "round-robin" }),
+	)
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", parent)
+	w := httptest.NewRecorder()
+	tracing.Middleware(tp.Tracer(tracing.TracerName), h).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	if err := tp.ForceFlush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want server span and two attempts", len(spans))
+	}
+
+	var serverSpan tracetest.SpanStub
+	var attempts []tracetest.SpanStub
+	for _, s := range spans {
+		if s.SpanKind == trace.SpanKindServer {
+			serverSpan = s
+		} else {
+			attempts = append(attempts, s)
+		}
+	}
+
+	if got := serverSpan.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("incoming trace context not continued, trace id %s", got)
+	}
+	attrs := make(map[string]string)
+	for _, kv := range serverSpan.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["lb.retry.count"] != "1" || attrs["lb.strategy"] != "round-robin" || attrs["lb.backend"] != ok.URL {
+		t.Errorf("server span attributes = %v", attrs)
+	}
+
+	// Бэкенд получает контекст клиентского спана своей попытки
+	if len(traceparents) != 2 {
+		t.Fatalf("backends got %d requests, want 2", len(traceparents))
+	}
+	for i, a := range attempts {
+		if a.Parent.SpanID() != serverSpan.SpanContext.SpanID() {
+			t.Errorf("attempt %d is not a child of the server span", i)
+		}
+		want := "00-" + a.SpanContext.TraceID().String() + "-" + a.SpanContext.SpanID().String() + "-01"
+		if traceparents[i] != want {
+			t.Errorf("attempt %d: traceparent = %q, want %q", i, traceparents[i], want)
+		}
+	}
+
+	mw := httptest.NewRecorder()
+	m.Handler().ServeHTTP(mw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(mw.Body)
+	for _, want := range []string{
+		`lb_upstream_requests_total{backend="` + unavailable.URL + `",code="5xx",method="GET"} 1`,
+		`lb_upstream_requests_total{backend="` + ok.URL + `",code="2xx",method="GET"} 1`,
+		`lb_upstream_in_flight_requests{backend="` + ok.URL + `"} 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Пакет sticky реализует привязку клиента к бэкенду через cookie:
+- В cookie хранится непрозрачный ID бэкенда, подписанный HMAC
+- Адрес бэкенда клиенту не раскрывается
+- Проверка доступности бэкенда остается за балансировщиком
+*/
+
+package sticky
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"load-balancer/internal/backend"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idLength длина ID бэкенда в байтах (до hex-кодирования)
+const idLength = 8
+
+type Sessions struct {
+	cookieName string
+	ttl        time.Duration
+	key        []byte
+
+	mu       sync.RWMutex
+	backends map[string]string // ID -> URL бэкенда
+}
+
+// New создает менеджер sticky-сессий. Если secret пустой, генерируется случайный ключ:
+// тогда cookie становятся недействительными после перезапуска, а реплики не понимают cookie друг друга.
+func New(cookieName, secret string, ttl time.Duration) *Sessions {
+	key := []byte(secret)
+	if len(key) == 0 {
+		slog.Warn("Sticky sessions: secret is not set, using random key")
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+
+	return &Sessions{
+		cookieName: cookieName,
+		ttl:        ttl,
+		key:        key,
+		backends:   make(map[string]string),
+	}
+}
+
+// SetBackends обновляет список известных бэкендов (из конфигурации, а не только живых)
+func (s *Sessions) SetBackends(backends []backend.Backend) {
+	m := make(map[string]string, len(backends))
+	for _, b := range backends {
+		m[s.id(b.URL)] = b.URL
+	}
+
+	s.mu.Lock()
+	s.backends = m
+	s.mu.Unlock()
+}
+
+// Backend возвращает бэкенд, закрепленный за клиентом, если cookie есть и подпись верна
+func (s *Sessions) Backend(r *http.Request) (string, bool) {
+	c, err := r.Cookie(s.cookieName)
+	if err != nil {
+		return "", false
+	}
+
+	id, sig, found := strings.Cut(c.Value, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(s.sign(id))) {
+		return "", false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	url, ok := s.backends[id]
+	return url, ok
+}
+
+// Cookie возвращает cookie, закрепляющую клиента за бэкендом
+func (s *Sessions) Cookie(r *http.Request, backendURL string) *http.Cookie {
+	id := s.id(backendURL)
+	return &http.Cookie{
+		Name:     s.cookieName,
+		Value:    id + "." + s.sign(id),
+		Path:     "/",
+		MaxAge:   int(s.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// id непрозрачный ID бэкенда: без ключа по нему нельзя восстановить URL
+func (s *Sessions) id(backendURL string) string {
+	return hex.EncodeToString(s.mac("backend:" + backendURL)[:idLength])
+}
+
+func (s *Sessions) sign(id string) string {
+	return base64.RawURLEncoding.EncodeToString(s.mac("cookie:" + id))
+}
+
+func (s *Sessions) mac(data string) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
@@ -0,0 +1,59 @@
+package sticky_test
+
+import (
+	"load-balancer/internal/backend"
+	"load-balancer/internal/sticky"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	s := sticky.New("lb_affinity", "secret", time.Hour)
+	s.SetBackends([]backend.Backend{{URL: "http://localhost:9001"}, {URL: "http://localhost:9002"}})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	cookie := s.Cookie(req, "http://localhost:9002")
+	if strings.Contains(cookie.Value, "localhost") {
+		t.Fatalf("cookie value leaks backend URL: %s", cookie.Value)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(cookie)
+		got, ok := s.Backend(req)
+		if !ok || got != "http://localhost:9002" {
+			t.Errorf("got %q, %v", got, ok)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		forged := *cookie
+		forged.Value = "0000000000000000" + forged.Value[16:]
+		req.AddCookie(&forged)
+		if _, ok := s.Backend(req); ok {
+			t.Error("forged cookie accepted")
+		}
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		other := sticky.New("lb_affinity", "another", time.Hour)
+		other.SetBackends([]backend.Backend{{URL: "http://localhost:9002"}})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(cookie)
+		if _, ok := other.Backend(req); ok {
+			t.Error("cookie signed with another secret accepted")
+		}
+	})
+
+	t.Run("removed backend", func(t *testing.T) {
+		s.SetBackends([]backend.Backend{{URL: "http://localhost:9001"}})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(cookie)
+		if _, ok := s.Backend(req); ok {
+			t.Error("cookie for removed backend accepted")
+		}
+	})
+}
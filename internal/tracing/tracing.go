@@ -0,0 +1,179 @@
+/*
+Пакет tracing реализует трассировку OpenTelemetry:
+- Провайдер с экспортером OTLP/HTTP или stdout и настраиваемым сэмплированием
+- Серверный спан на каждый входящий запрос
+- Распространение W3C trace context (traceparent/tracestate)
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"net/http"
+	"os"
+)
+
+// Экспортеры в конфигурации
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// TracerName имя трассировщика балансировщика
+const TracerName = "load-balancer"
+
+// Атрибуты спанов балансировщика
+const (
+	AttrBackend           = attribute.Key("lb.backend")
+	AttrStrategy          = attribute.Key("lb.strategy")
+	AttrRetryCount        = attribute.Key("lb.retry.count") // Повторов на других бэкендах
+	AttrAttempt           = attribute.Key("lb.attempt")     // Номер попытки, начиная с 1
+	AttrRateLimitDecision = attribute.Key("lb.ratelimit.decision")
+	AttrRateLimitRule     = attribute.Key("lb.ratelimit.rule")
+)
+
+// Propagator W3C trace context и baggage
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Config параметры трассировки
+type Config struct {
+	Exporter    string  // otlp | stdout
+	Endpoint    string  // otlp: host:port коллектора; пусто - из OTEL_EXPORTER_OTLP_ENDPOINT или localhost:4318
+	Insecure    bool    // otlp: без TLS
+	SampleRatio float64 // Доля трассируемых запросов без родительского спана, 0..1
+	ServiceName string
+}
+
+// Setup создает провайдер по конфигурации и устанавливает его и Propagator глобально.
+// Провайдер нужно остановить через Shutdown, чтобы отправить накопленные спаны.
+func Setup(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tp := NewProvider(exporter, cfg.SampleRatio, cfg.ServiceName)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator)
+	return tp, nil
+}
+
+// NewProvider создает провайдер с экспортером (например, tracetest.InMemoryExporter в тестах).
+// Запросы с родительским спаном трассируются по решению родителя, остальные - с долей sampleRatio.
+func NewProvider(exporter sdktrace.SpanExporter, sampleRatio float64, serviceName string) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(serviceName))
+	if merged, err := resource.Merge(resource.Default(), res); err == nil {
+		res = merged
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+}
+
+// Middleware создает серверный спан на каждый входящий запрос. Контекст трассировки
+// клиента берется из traceparent/tracestate; спан доступен обработчикам через trace.SpanFromContext.
+func Middleware(tracer trace.Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ServerAddress(r.Host),
+				semconv.ClientAddress(clientAddress(r)),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		status := sw.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		SetStatus(span, status)
+	})
+}
+
+// Inject добавляет контекст трассировки из ctx в заголовки запроса к бэкенду
+func Inject(ctx context.Context, h http.Header) {
+	Propagator.Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// SetStatus отмечает спан ошибочным при ответе 5xx
+func SetStatus(span trace.Span, status int) {
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+func clientAddress(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// statusWriter запоминает код ответа
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap нужен http.ResponseController (Flush при проксировании потоковых ответов)
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
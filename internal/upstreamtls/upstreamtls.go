@@ -0,0 +1,58 @@
+/*
+Пакет upstreamtls реализует параметры TLS соединений балансировщика с бэкендами https:
+- Собственные корневые сертификаты для проверки бэкенда
+- Клиентский сертификат для mTLS
+- Переопределение SNI
+- Отключение проверки сертификата
+*/
+
+package upstreamtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Config параметры TLS соединения с бэкендом. Пустой Config - параметры Go по умолчанию.
+type Config struct {
+	CAFile             string // PEM-бандл корневых сертификатов; пусто - системные
+	CertFile           string // Клиентский сертификат (PEM) для mTLS
+	KeyFile            string
+	ServerName         string // SNI и имя для проверки сертификата; пусто - хост бэкенда
+	InsecureSkipVerify bool
+}
+
+// New читает файлы и собирает tls.Config. Для пустого Config возвращает nil.
+func New(c Config) (*tls.Config, error) {
+	if c == (Config{}) {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		data, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA bundle %s: no PEM certificates found", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,39 @@
+package upstreamtls_test
+
+import (
+	"load-balancer/internal/upstreamtls"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	cfg, err := upstreamtls.New(upstreamtls.Config{})
+	if cfg != nil || err != nil {
+		t.Errorf("empty config: got %v, %v; want nil, nil", cfg, err)
+	}
+
+	cfg, err = upstreamtls.New(upstreamtls.Config{ServerName: "api.internal", InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ServerName != "api.internal" || !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []upstreamtls.Config{
+		{CAFile: filepath.Join(dir, "missing.pem")},
+		{CAFile: garbage},
+		{CertFile: garbage, KeyFile: garbage},
+		{CertFile: garbage}, // Сертификат без ключа
+	} {
+		if _, err := upstreamtls.New(c); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
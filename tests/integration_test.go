@@ -51,7 +51,7 @@ func startBalancerServer(cfgPath string) {
 	cmd := exec.Command(
 		"go",
 		"run",
-		filepath.Join(root, "cmd/balancer/main.go"),
+		"./cmd/balancer", // Пакет целиком: часть main собирается из файлов с build-тегами
 		"-config",
 		configPath,
 	)